@@ -0,0 +1,197 @@
+package bitvec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Update is a state to set at an index in a batch.
+type Update struct {
+	// Index is the index of the response
+	Index uint64
+	// State is the state to set for the response
+	State uint64
+}
+
+// validateUpdates checks every update of a batch against the count and maximum state of a vector.
+// Returns an error listing all invalid updates by their position in the batch, or nil if all are valid.
+func validateUpdates(updates []Update, count, max uint64, name string) error {
+	invalid := make([]string, 0)
+	for i, update := range updates {
+		if update.Index >= count {
+			invalid = append(invalid, fmt.Sprintf("%v: index too large for %v count (max: %v)", i, name, count))
+		} else if update.State > max {
+			invalid = append(invalid, fmt.Sprintf("%v: state too large for %v state (max: %v)", i, name, max))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return errors.Errorf("invalid %v batch entries [%v]", name, strings.Join(invalid, ", "))
+	}
+
+	return nil
+}
+
+// validateIndexes checks every index of a batch against the count of a vector.
+// Returns an error listing all invalid indexes by their position in the batch, or nil if all are valid.
+func validateIndexes(indexes []uint64, count uint64, name string) error {
+	invalid := make([]string, 0)
+	for i, index := range indexes {
+		if index >= count {
+			invalid = append(invalid, fmt.Sprintf("%v: index too large for %v count (max: %v)", i, name, count))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return errors.Errorf("invalid %v batch entries [%v]", name, strings.Join(invalid, ", "))
+	}
+
+	return nil
+}
+
+// setUpdates merges the state of every update into the data, in the order of their words.
+// The updates must have been validated.
+func setUpdates(data []uint64, layout Layout, size uint64, updates []Update) {
+	sorted := make([]Update, len(updates))
+	copy(sorted, updates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	for _, update := range sorted {
+		pos := update.Index * size
+		writeBits(data, layout, pos, size, readBits(data, layout, pos, size)|update.State)
+	}
+}
+
+// unsetIndexes clears the state of every index in the data, in the order of their words.
+// The indexes must have been validated.
+func unsetIndexes(data []uint64, layout Layout, size uint64, indexes []uint64) {
+	sorted := make([]uint64, len(indexes))
+	copy(sorted, indexes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, index := range sorted {
+		writeBits(data, layout, index*size, size, 0)
+	}
+}
+
+// SetBatch is a method of BitVec that sets the states of a batch of updates under a single lock, like Set.
+// Either all updates are applied or none: returns an error listing every update whose index is out of bounds
+// or whose state value exceeds the maximum for the BitVec.
+func (vec *BitVec) SetBatch(updates []Update) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check all updates before modifying the BitVec
+	if err := validateUpdates(updates, vec.Count, vec.MaxState(), "bitvec"); err != nil {
+		return err
+	}
+
+	setUpdates(vec.Data, vec.Layout, vec.Size, updates)
+	vec.modified()
+
+	return nil
+}
+
+// UnsetBatch is a method of BitVec that unsets the states for a batch of indexes under a single lock, like Unset.
+// Either all indexes are unset or none: returns an error listing every index that is out of bounds.
+func (vec *BitVec) UnsetBatch(indexes []uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check all indexes before modifying the BitVec
+	if err := validateIndexes(indexes, vec.Count, "bitvec"); err != nil {
+		return err
+	}
+
+	unsetIndexes(vec.Data, vec.Layout, vec.Size, indexes)
+	vec.modified()
+
+	return nil
+}
+
+// SetBatch is a method of DiBit that sets the states of a batch of updates under a single lock, like Set.
+// Either all updates are applied or none: returns an error listing every update whose index is out of bounds
+// or whose state value exceeds the maximum for the DiBit.
+func (vec *DiBit) SetBatch(updates []Update) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check all updates before modifying the DiBit
+	if err := validateUpdates(updates, vec.Count, vec.MaxState(), "dibit"); err != nil {
+		return err
+	}
+
+	setUpdates(vec.Data, vec.Layout, DIBITSIZE, updates)
+	vec.modified()
+
+	return nil
+}
+
+// UnsetBatch is a method of DiBit that unsets the states for a batch of indexes under a single lock, like Unset.
+// Either all indexes are unset or none: returns an error listing every index that is out of bounds.
+func (vec *DiBit) UnsetBatch(indexes []uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check all indexes before modifying the DiBit
+	if err := validateIndexes(indexes, vec.Count, "dibit"); err != nil {
+		return err
+	}
+
+	unsetIndexes(vec.Data, vec.Layout, DIBITSIZE, indexes)
+	vec.modified()
+
+	return nil
+}
+
+// statesAt reads the states at the given indexes of the data into dst.
+// Returns an error for the first index that is out of bounds or if dst is shorter than indexes.
+func statesAt(data []uint64, layout Layout, count, size uint64, indexes, dst []uint64, name string) error {
+	if len(dst) < len(indexes) {
+		return errors.Errorf("destination too short for indexes (want: %v)", len(indexes))
+	}
+
+	// Check all indexes before reading any state
+	for i, index := range indexes {
+		if index >= count {
+			return errors.Errorf("%v: index too large for %v count (max: %v)", i, name, count)
+		}
+	}
+
+	for i, index := range indexes {
+		dst[i] = readBits(data, layout, index*size, size)
+	}
+
+	return nil
+}
+
+// StatesAt is a method of BitVec that reads the states at the given indexes into dst,
+// so that dst[i] is the state at indexes[i], from a single consistent read of the BitVec.
+// Returns an error for the first index that is out of bounds or if dst is shorter than indexes,
+// in which case dst is not modified.
+func (vec *BitVec) StatesAt(indexes, dst []uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return statesAt(vec.Data, vec.Layout, vec.Count, vec.Size, indexes, dst, "bitvec")
+}
+
+// StatesAt is a method of DiBit that reads the states at the given indexes into dst,
+// so that dst[i] is the state at indexes[i], from a single consistent read of the DiBit.
+// Returns an error for the first index that is out of bounds or if dst is shorter than indexes,
+// in which case dst is not modified.
+func (vec *DiBit) StatesAt(indexes, dst []uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return statesAt(vec.Data, vec.Layout, vec.Count, DIBITSIZE, indexes, dst, "dibit")
+}
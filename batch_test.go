@@ -0,0 +1,157 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_SetBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(39))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		vec, err := NewBitVecWithLayout(1000, 7, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		batch, err := NewBitVecWithLayout(1000, 7, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		updates := make([]Update, 0)
+		for n := 0; n < 2000; n++ {
+			update := Update{uint64(rng.Intn(1000)), uint64(rng.Intn(128))}
+			updates = append(updates, update)
+			require.Nil(t, vec.Set(update.Index, update.State))
+		}
+
+		require.Nil(t, batch.SetBatch(updates))
+		assert.Equal(t, vec.Data, batch.Data)
+
+		indexes := make([]uint64, 0)
+		for n := 0; n < 500; n++ {
+			index := uint64(rng.Intn(1000))
+			indexes = append(indexes, index)
+			require.Nil(t, vec.Unset(index))
+		}
+
+		require.Nil(t, batch.UnsetBatch(indexes))
+		assert.Equal(t, vec.Data, batch.Data)
+	}
+
+	// The updates of the caller are not reordered
+	vec, _ := NewBitVec(10, 2)
+	updates := []Update{{9, 1}, {2, 3}, {5, 2}}
+	require.Nil(t, vec.SetBatch(updates))
+	assert.Equal(t, []Update{{9, 1}, {2, 3}, {5, 2}}, updates)
+
+	// The rank directory is rebuilt after a batch
+	rank, err := vec.Rank(3, 10)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(1), rank)
+
+	require.Nil(t, vec.UnsetBatch([]uint64{2}))
+	rank, err = vec.Rank(3, 10)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(0), rank)
+}
+
+func TestBitVec_SetBatch_Errors(t *testing.T) {
+	vec, _ := NewBitVec(10, 2)
+	require.Nil(t, vec.Set(1, 1))
+
+	// No update is applied if any is invalid
+	err := vec.SetBatch([]Update{{0, 3}, {10, 1}, {4, 2}, {5, 4}})
+	assert.EqualError(t, err, "invalid bitvec batch entries ["+
+		"1: index too large for bitvec count (max: 10), "+
+		"3: state too large for bitvec state (max: 3)]")
+
+	err = vec.UnsetBatch([]uint64{1, 12})
+	assert.EqualError(t, err, "invalid bitvec batch entries [1: index too large for bitvec count (max: 10)]")
+
+	assert.Equal(t, []uint64{0x1000000000000000}, vec.Data)
+	require.Nil(t, vec.SetBatch(nil))
+	require.Nil(t, vec.UnsetBatch(nil))
+}
+
+func TestDiBit_SetBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(39))
+
+	vec := NewDiBit(1000)
+	batch := NewDiBit(1000)
+
+	updates := make([]Update, 0)
+	indexes := make([]uint64, 0)
+	for n := 0; n < 2000; n++ {
+		update := Update{uint64(rng.Intn(1000)), uint64(rng.Intn(4))}
+		updates = append(updates, update)
+		require.Nil(t, vec.Set(update.Index, update.State))
+	}
+
+	for n := 0; n < 500; n++ {
+		index := uint64(rng.Intn(1000))
+		indexes = append(indexes, index)
+		require.Nil(t, vec.Unset(index))
+	}
+
+	require.Nil(t, batch.SetBatch(updates))
+	require.Nil(t, batch.UnsetBatch(indexes))
+	assert.Equal(t, vec.Data, batch.Data)
+
+	err := batch.SetBatch([]Update{{1000, 1}, {0, 4}})
+	assert.EqualError(t, err, "invalid dibit batch entries ["+
+		"0: index too large for dibit count (max: 1000), "+
+		"1: state too large for dibit state (max: 3)]")
+
+	err = batch.UnsetBatch([]uint64{1000})
+	assert.EqualError(t, err, "invalid dibit batch entries [0: index too large for dibit count (max: 1000)]")
+}
+
+func TestBitVec_StatesAt(t *testing.T) {
+	rng := rand.New(rand.NewSource(40))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		vec, err := NewBitVecWithLayout(700, 11, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		for n := 0; n < 500; n++ {
+			require.Nil(t, vec.Set(uint64(rng.Intn(700)), uint64(rng.Intn(2048))))
+		}
+
+		indexes := make([]uint64, 300)
+		expected := make([]uint64, 300)
+		for i := range indexes {
+			indexes[i] = uint64(rng.Intn(700))
+			expected[i], _ = vec.State(indexes[i])
+		}
+
+		// The destination may be longer than the indexes
+		dst := make([]uint64, 310)
+		require.Nil(t, vec.StatesAt(indexes, dst))
+		assert.Equal(t, expected, dst[:300])
+		assert.Equal(t, make([]uint64, 10), dst[300:])
+	}
+
+	vec, _ := NewBitVec(10, 2)
+	require.Nil(t, vec.Set(4, 3))
+
+	dst := []uint64{7, 7, 7}
+	assert.EqualError(t, vec.StatesAt([]uint64{4, 10, 11}, dst), "1: index too large for bitvec count (max: 10)")
+	assert.Equal(t, []uint64{7, 7, 7}, dst)
+
+	assert.EqualError(t, vec.StatesAt([]uint64{4, 1}, dst[:1]), "destination too short for indexes (want: 2)")
+	require.Nil(t, vec.StatesAt(nil, nil))
+}
+
+func TestDiBit_StatesAt(t *testing.T) {
+	vec := NewDiBit(100)
+	require.Nil(t, vec.Set(31, 2))
+	require.Nil(t, vec.Set(32, 3))
+	require.Nil(t, vec.Set(99, 1))
+
+	dst := make([]uint64, 4)
+	require.Nil(t, vec.StatesAt([]uint64{99, 32, 0, 31}, dst))
+	assert.Equal(t, []uint64{1, 3, 0, 2}, dst)
+
+	assert.EqualError(t, vec.StatesAt([]uint64{100}, dst), "0: index too large for dibit count (max: 100)")
+}
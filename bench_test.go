@@ -14,6 +14,18 @@ func BenchmarkConstruct(b *testing.B) {
 			_ = NewDiBit(100)
 		}
 	})
+
+	b.Run("UnsafeBitVec", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = NewUnsafeBitVec(100, 2)
+		}
+	})
+
+	b.Run("UnsafeDiBit", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = NewUnsafeDiBit(100)
+		}
+	})
 }
 
 func BenchmarkSet(b *testing.B) {
@@ -36,6 +48,24 @@ func BenchmarkSet(b *testing.B) {
 		}
 	})
 
+	b.Run("UnsafeBitVec", func(b *testing.B) {
+		vec, _ := NewUnsafeBitVec(100, 2)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_ = vec.Set(79, 3)
+		}
+	})
+
+	b.Run("UnsafeDiBit", func(b *testing.B) {
+		vec := NewUnsafeDiBit(100)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_ = vec.Set(79, 3)
+		}
+	})
+
 }
 func BenchmarkUnset(b *testing.B) {
 
@@ -61,6 +91,28 @@ func BenchmarkUnset(b *testing.B) {
 		}
 	})
 
+	b.Run("UnsafeBitVec", func(b *testing.B) {
+		vec, _ := NewUnsafeBitVec(100, 2)
+		_ = vec.Set(79, 3)
+		_ = vec.Set(81, 1)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_ = vec.Unset(81)
+		}
+	})
+
+	b.Run("UnsafeDiBit", func(b *testing.B) {
+		vec := NewUnsafeDiBit(100)
+		_ = vec.Set(79, 3)
+		_ = vec.Set(81, 1)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_ = vec.Unset(81)
+		}
+	})
+
 }
 
 func BenchmarkHas(b *testing.B) {
@@ -87,6 +139,28 @@ func BenchmarkHas(b *testing.B) {
 		}
 	})
 
+	b.Run("UnsafeBitVec", func(b *testing.B) {
+		vec, _ := NewUnsafeBitVec(100, 2)
+		_ = vec.Set(79, 3)
+		_ = vec.Set(81, 1)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = vec.Has(79, 3)
+		}
+	})
+
+	b.Run("UnsafeDiBit", func(b *testing.B) {
+		vec := NewUnsafeDiBit(100)
+		_ = vec.Set(79, 3)
+		_ = vec.Set(81, 1)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = vec.Has(79, 3)
+		}
+	})
+
 }
 
 func BenchmarkState(b *testing.B) {
@@ -113,4 +187,26 @@ func BenchmarkState(b *testing.B) {
 		}
 	})
 
+	b.Run("UnsafeBitVec", func(b *testing.B) {
+		vec, _ := NewUnsafeBitVec(100, 2)
+		_ = vec.Set(79, 3)
+		_ = vec.Set(81, 1)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = vec.State(81)
+		}
+	})
+
+	b.Run("UnsafeDiBit", func(b *testing.B) {
+		vec := NewUnsafeDiBit(100)
+		_ = vec.Set(79, 3)
+		_ = vec.Set(81, 1)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = vec.State(81)
+		}
+	})
+
 }
@@ -0,0 +1,104 @@
+package bitvec
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// The big.Int mapping used by ToBigInt and FromBigInt is independent of the Layout
+// of a vector: the state of slot i occupies the integer bits [i*Size, (i+1)*Size),
+// with the least significant bit of the state at integer bit i*Size. Slot 0 is
+// therefore the least significant state of the integer and, for a Size of 1,
+// big.Int.Bit(i) is the state of slot i.
+
+// toBigInt returns the big.Int for count slots of size bits packed into data in the given layout.
+func toBigInt(data []uint64, count, size uint64, layout Layout) *big.Int {
+	// The LSBFirst layout matches the integer bit order word for word
+	words := relayout(data, count, size, layout, LSBFirst)
+
+	// Encode the words as a big-endian byte string, most significant word first
+	buf := make([]byte, len(words)*8)
+	for i, word := range words {
+		binary.BigEndian.PutUint64(buf[(len(words)-1-i)*8:], word)
+	}
+
+	return new(big.Int).SetBytes(buf)
+}
+
+// fromBigInt returns the data for count slots of size bits in the given layout from a big.Int.
+// Returns an error if the integer is negative or does not fit into count*size bits.
+func fromBigInt(x *big.Int, count, size uint64, layout Layout, words int) ([]uint64, error) {
+	// Check for a negative integer
+	if x.Sign() < 0 {
+		return nil, errors.New("negative integer not allowed")
+	}
+
+	// Check for an integer with more bits than the slots can hold
+	if uint64(x.BitLen()) > count*size {
+		return nil, errors.Errorf("integer too large for count and size (max bits: %v)", count*size)
+	}
+
+	// Decode the words from a big-endian byte string, most significant word first
+	buf := x.FillBytes(make([]byte, words*8))
+	data := make([]uint64, words)
+	for i := range data {
+		data[i] = binary.BigEndian.Uint64(buf[(words-1-i)*8:])
+	}
+
+	return relayout(data, count, size, LSBFirst, layout), nil
+}
+
+// ToBigInt is a method of BitVec that returns the responses as a big.Int.
+// The state of slot i occupies the integer bits [i*Size, (i+1)*Size), regardless of Layout.
+func (vec *BitVec) ToBigInt() *big.Int {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return toBigInt(vec.Data, vec.Count, vec.Size, vec.Layout)
+}
+
+// FromBigInt is a constructor function for a BitVec with the MSBFirst layout from a big.Int.
+// The state of slot i is read from the integer bits [i*Size, (i+1)*Size).
+// Returns an error if Size is greater than MAXVECSIZE or if the integer is negative or too large.
+func FromBigInt(count, size uint64, x *big.Int) (*BitVec, error) {
+	vec, err := NewBitVec(count, size)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fromBigInt(x, count, size, vec.Layout, len(vec.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	vec.Data = data
+	return vec, nil
+}
+
+// ToBigInt is a method of DiBit that returns the responses as a big.Int.
+// The state of slot i occupies the integer bits [i*2, (i+1)*2), regardless of Layout.
+func (vec *DiBit) ToBigInt() *big.Int {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return toBigInt(vec.Data, vec.Count, DIBITSIZE, vec.Layout)
+}
+
+// DiBitFromBigInt is a constructor function for a DiBit with the MSBFirst layout from a big.Int.
+// The state of slot i is read from the integer bits [i*2, (i+1)*2).
+// Returns an error if the integer is negative or too large.
+func DiBitFromBigInt(count uint64, x *big.Int) (*DiBit, error) {
+	vec := NewDiBit(count)
+
+	data, err := fromBigInt(x, count, DIBITSIZE, vec.Layout, len(vec.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	vec.Data = data
+	return vec, nil
+}
@@ -0,0 +1,81 @@
+package bitvec
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_ToBigInt(t *testing.T) {
+	tests := []struct {
+		bitvec *BitVec
+		output string
+	}{
+		{
+			&BitVec{Count: 4, Size: 2, Data: []uint64{0x1B00000000000000}},
+			"0b11100100",
+		},
+		{
+			&BitVec{Count: 4, Size: 2, Layout: LSBFirst, Data: []uint64{0x1B}},
+			"0b11011",
+		},
+		{
+			&BitVec{Count: 42, Size: 3, Data: []uint64{1, 4611686018427387904}},
+			"0b101" + "000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			&BitVec{Count: 0, Size: 3, Data: []uint64{}},
+			"0b0",
+		},
+	}
+
+	for _, test := range tests {
+		x := test.bitvec.ToBigInt()
+		assert.Equal(t, test.output, "0b"+x.Text(2))
+
+		vec, err := FromBigInt(test.bitvec.Count, test.bitvec.Size, x)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, test.bitvec.ToStates(), vec.ToStates())
+	}
+}
+
+func TestFromBigInt(t *testing.T) {
+	vec, err := FromBigInt(70, 1, new(big.Int).Lsh(big.NewInt(1), 65))
+	require.Nil(t, err, "Unexpected Error")
+
+	indexes, err := vec.Indexes(1)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{65}, indexes)
+
+	vec, err = FromBigInt(4, 2, big.NewInt(256))
+	assert.EqualError(t, err, "integer too large for count and size (max bits: 8)")
+	assert.Nil(t, vec)
+
+	vec, err = FromBigInt(4, 2, big.NewInt(-1))
+	assert.EqualError(t, err, "negative integer not allowed")
+	assert.Nil(t, vec)
+
+	vec, err = FromBigInt(4, 65, big.NewInt(1))
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+	assert.Nil(t, vec)
+}
+
+func TestDiBit_ToBigInt(t *testing.T) {
+	vec := NewDiBit(40)
+	require.Nil(t, vec.Set(0, 2))
+	require.Nil(t, vec.Set(33, 3))
+
+	x := vec.ToBigInt()
+	expected := new(big.Int).Or(big.NewInt(2), new(big.Int).Lsh(big.NewInt(3), 66))
+	assert.Equal(t, 0, expected.Cmp(x))
+
+	dibit, err := DiBitFromBigInt(40, x)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, vec.Data, dibit.Data)
+
+	dibit, err = DiBitFromBigInt(2, x)
+	assert.EqualError(t, err, "integer too large for count and size (max bits: 4)")
+	assert.Nil(t, dibit)
+}
@@ -0,0 +1,125 @@
+package bitvec
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// The binary serialization format of a BitVec is a fixed size header followed by the words of its
+// Data, each encoded little-endian. The header is a multiple of 8 bytes so that the words are aligned
+// when the format is memory-mapped. All header fields are little-endian.
+//
+//	offset  size  field
+//	0       4     magic "BVEC"
+//	4       1     format version (BINARYVERSION)
+//	5       1     Layout
+//	6       2     reserved, zero
+//	8       8     Size
+//	16      8     Count
+const (
+	// BINARYVERSION is the version of the binary serialization format.
+	BINARYVERSION = 1
+	// BINARYHEADERSIZE is the number of bytes of the header of the binary serialization format.
+	BINARYHEADERSIZE = 24
+)
+
+// binaryMagic identifies the binary serialization format.
+var binaryMagic = [4]byte{'B', 'V', 'E', 'C'}
+
+// encodeHeader returns the header of the binary serialization format.
+func encodeHeader(count, size uint64, layout Layout) []byte {
+	header := make([]byte, BINARYHEADERSIZE)
+	copy(header, binaryMagic[:])
+	header[4] = BINARYVERSION
+	header[5] = byte(layout)
+	binary.LittleEndian.PutUint64(header[8:], size)
+	binary.LittleEndian.PutUint64(header[16:], count)
+
+	return header
+}
+
+// checkCount returns an error if the count*size bits of a vector, rounded up to whole words, overflow a uint64.
+func checkCount(count, size uint64) error {
+	if size != 0 && count > (math.MaxUint64-63)/size {
+		return errors.Errorf("count too large for bitvec size (max: %v)", (math.MaxUint64-63)/size)
+	}
+
+	return nil
+}
+
+// decodeHeader returns the count, size and layout from the header of the binary serialization format.
+func decodeHeader(header []byte) (count, size uint64, layout Layout, err error) {
+	if len(header) < BINARYHEADERSIZE {
+		return 0, 0, 0, errors.New("binary data too short for header")
+	}
+
+	if [4]byte{header[0], header[1], header[2], header[3]} != binaryMagic {
+		return 0, 0, 0, errors.New("binary data has invalid magic")
+	}
+
+	if header[4] != BINARYVERSION {
+		return 0, 0, 0, errors.Errorf("binary data has unsupported version: %v", header[4])
+	}
+
+	layout = Layout(header[5])
+	if err := layout.validate(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	size = binary.LittleEndian.Uint64(header[8:])
+	if size > MAXVECSIZE {
+		return 0, 0, 0, errors.New("state size greater 64 not allowed")
+	}
+
+	count = binary.LittleEndian.Uint64(header[16:])
+	if err := checkCount(count, size); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return count, size, layout, nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface for BitVec
+func (vec *BitVec) MarshalBinary() ([]byte, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	output := make([]byte, BINARYHEADERSIZE+8*len(vec.Data))
+	copy(output, encodeHeader(vec.Count, vec.Size, vec.Layout))
+
+	for i, word := range vec.Data {
+		binary.LittleEndian.PutUint64(output[BINARYHEADERSIZE+8*i:], word)
+	}
+
+	return output, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface for BitVec
+func (vec *BitVec) UnmarshalBinary(data []byte) error {
+	count, size, layout, err := decodeHeader(data)
+	if err != nil {
+		return err
+	}
+
+	// Check that the data holds exactly the words for the Count and Size
+	words := (count*size + 63) / 64
+	if uint64(len(data)-BINARYHEADERSIZE) != words*8 {
+		return errors.Errorf("binary data length does not match bitvec count and size (want: %v)", BINARYHEADERSIZE+words*8)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.Count, vec.Size, vec.Layout = count, size, layout
+	vec.Data = make([]uint64, words)
+	for i := range vec.Data {
+		vec.Data[i] = binary.LittleEndian.Uint64(data[BINARYHEADERSIZE+8*i:])
+	}
+
+	vec.rank = nil
+	return nil
+}
@@ -0,0 +1,53 @@
+package bitvec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_MarshalBinary(t *testing.T) {
+	vec := &BitVec{Count: 42, Size: 3, Layout: LSBFirst, Data: []uint64{1, 4611686018427387904}}
+
+	data, err := vec.MarshalBinary()
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []byte{
+		'B', 'V', 'E', 'C', 1, 1, 0, 0,
+		3, 0, 0, 0, 0, 0, 0, 0,
+		42, 0, 0, 0, 0, 0, 0, 0,
+		1, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0x40,
+	}, data)
+
+	output := new(BitVec)
+	require.Nil(t, output.UnmarshalBinary(data))
+	assert.Equal(t, vec.Count, output.Count)
+	assert.Equal(t, vec.Size, output.Size)
+	assert.Equal(t, vec.Layout, output.Layout)
+	assert.Equal(t, vec.Data, output.Data)
+}
+
+func TestBitVec_UnmarshalBinary(t *testing.T) {
+	header := encodeHeader(42, 3, MSBFirst)
+
+	tests := []struct {
+		data []byte
+		err  string
+	}{
+		{header[:10], "binary data too short for header"},
+		{append([]byte("BVEX"), header[4:]...), "binary data has invalid magic"},
+		{append(append([]byte{}, header[:4]...), append([]byte{2}, header[5:]...)...), "binary data has unsupported version: 2"},
+		{append(append([]byte{}, header[:5]...), append([]byte{5}, header[6:]...)...), "unknown bit layout: 5"},
+		{header, "binary data length does not match bitvec count and size (want: 40)"},
+		{encodeHeader(1, 65, MSBFirst), "state size greater 64 not allowed"},
+		{encodeHeader(1<<63, 2, MSBFirst), "count too large for bitvec size (max: 9223372036854775776)"},
+		{encodeHeader(math.MaxUint64, 1, MSBFirst), "count too large for bitvec size (max: 18446744073709551552)"},
+	}
+
+	for _, test := range tests {
+		vec := new(BitVec)
+		assert.EqualError(t, vec.UnmarshalBinary(test.data), test.err)
+	}
+}
@@ -21,20 +21,36 @@ type BitVec struct {
 	Count uint64
 	// Size is the number of bits required for a response
 	Size uint64
+	// Layout is the order in which responses are packed into Data
+	Layout Layout
 	// Data stores the responses according to their indices
 	Data []uint64
+
+	// rank is the optional auxiliary rank directory over Data
+	rank *rankDirectory
 }
 
-// NewBitVec is a constructor function for BitVec.
+// NewBitVec is a constructor function for BitVec with the default MSBFirst layout.
 // Returns an error if Size is greater than MAXVECSIZE
 func NewBitVec(count, size uint64) (*BitVec, error) {
+	return NewBitVecWithLayout(count, size, MSBFirst)
+}
+
+// NewBitVecWithLayout is a constructor function for BitVec with the given Layout.
+// Returns an error if Size is greater than MAXVECSIZE or if the Layout is unknown.
+func NewBitVecWithLayout(count, size uint64, layout Layout) (*BitVec, error) {
 	// Check if given Size is under MAXVECSIZE
 	if size > MAXVECSIZE {
 		return nil, errors.New("state size greater 64 not allowed")
 	}
 
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
 	return &BitVec{
-		mu: sync.Mutex{}, Count: count, Size: size,
+		mu: sync.Mutex{}, Count: count, Size: size, Layout: layout,
 		Data: make([]uint64, int(math.Ceil(float64(count*size)/64))),
 	}, nil
 }
@@ -50,97 +66,85 @@ func (vec *BitVec) MaxState() uint64 {
 	return 1<<vec.Size - 1
 }
 
-// Set is a method of BitVec that sets a given state at given index.
-// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the BitVec.
-func (vec *BitVec) Set(index, state uint64) error {
-	// Check for out of bounds index
-	if index >= vec.Count {
-		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+// modified marks any auxiliary indexes over the Data of the BitVec as out of date.
+// It must be called with the mutex held by every method that writes to the Data.
+func (vec *BitVec) modified() {
+	if vec.rank != nil {
+		vec.rank.stale = true
 	}
+}
 
-	// Check for state value too large for BitVec
-	if state > vec.MaxState() {
-		return errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+// Relayout is a method of BitVec that repacks the responses into the given Layout.
+// The states of all responses are preserved. Returns an error if the Layout is unknown.
+func (vec *BitVec) Relayout(layout Layout) error {
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return err
 	}
 
 	// Acquire the mutex
 	vec.mu.Lock()
 	defer vec.mu.Unlock()
 
-	// Get the start and end positions for the response state in the Data
-	start := (index * vec.Size) / 64
-	end := (((index + 1) * vec.Size) - 1) / 64
+	copy(vec.Data, relayout(vec.Data, vec.Count, vec.Size, vec.Layout, layout))
+	vec.Layout = layout
+	vec.modified()
 
-	// Calculate the start and end bit positions
-	startBit := (index * vec.Size) % 64
-	endBit := (((index + 1) * vec.Size) - 1) % 64
-
-	// If the response is contained within a single uint64 in Data
-	if start == end {
-		temp := state << (64 - vec.Size - startBit)
-		vec.Data[start] |= temp
+	return nil
+}
 
-	} else {
-		// Calculate new values for both affected positions.
-		// NOTE: This logic fails for a response that spans beyond 2 uint64.
-		// This is regulated by MAXVECSIZE.
+// Set is a method of BitVec that sets a given state at given index.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the BitVec.
+func (vec *BitVec) Set(index, state uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
 
-		startTemp := state >> (vec.Size - 64 + startBit)
-		endTemp := state << (64 - endBit - 1)
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
 
-		vec.Data[start] |= startTemp
-		vec.Data[end] |= endTemp
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
 	}
 
+	// Merge the state into the bits of the response at its position in the Data.
+	// NOTE: A response spans at most 2 uint64 in Data. This is regulated by MAXVECSIZE.
+	pos := index * vec.Size
+	writeBits(vec.Data, vec.Layout, pos, vec.Size, readBits(vec.Data, vec.Layout, pos, vec.Size)|state)
+	vec.modified()
+
 	return nil
 }
 
 // Unset is a method of BitVec that unsets the state for a given index.
 // Returns an error index is out of bounds.
 func (vec *BitVec) Unset(index uint64) error {
-	// Check for out of bounds index
-	if index >= vec.Count {
-		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
-	}
-
 	// Acquire the mutex
 	vec.mu.Lock()
 	defer vec.mu.Unlock()
 
-	// Get the start and end positions for the response state in the Data
-	start := (index * vec.Size) / 64
-	end := (((index + 1) * vec.Size) - 1) / 64
-
-	// Calculate the start and end bit positions
-	startBit := (index * vec.Size) % 64
-	endBit := (((index + 1) * vec.Size) - 1) % 64
-
-	// If the response is contained within a single uint64 in Data
-	if start == end {
-		temp := vec.MaxState() << (64 - vec.Size - startBit)
-		temp ^= 1<<64 - 1
-
-		vec.Data[start] &= temp
-
-	} else {
-		// Calculate new values for both affected positions.
-		// NOTE: This logic fails for a response that spans beyond 2 uint64.
-		// This is regulated by MAXVECSIZE.
-
-		max := uint64(1<<64 - 1)
-		startTemp := max << (64 - startBit)
-		endTemp := max >> (endBit + 1)
-
-		vec.Data[start] &= startTemp
-		vec.Data[end] &= endTemp
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
 	}
 
+	// Clear the bits of the response at its position in the Data
+	writeBits(vec.Data, vec.Layout, index*vec.Size, vec.Size, 0)
+	vec.modified()
+
 	return nil
 }
 
 // Has is a method of BitVec that checks whether the state at a given index matches the given state.
 // Returns an error if the index is out of bounds or if the state value exceeds the maximum for the BitVec.
 func (vec *BitVec) Has(index, state uint64) (bool, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
 	// Check for out of bounds index
 	if index >= vec.Count {
 		return false, errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
@@ -151,84 +155,22 @@ func (vec *BitVec) Has(index, state uint64) (bool, error) {
 		return false, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
 	}
 
-	// Get the start and end positions for the response state in the Data
-	start := (index * vec.Size) / 64
-	end := (((index + 1) * vec.Size) - 1) / 64
-
-	// Calculate the start and end bit positions
-	startBit := (index * vec.Size) % 64
-	endBit := (((index + 1) * vec.Size) - 1) % 64
-
-	var value uint64
-	max := vec.MaxState()
-
-	// If the response is contained within a single uint64 in Data
-	if start == end {
-		temp := max << (64 - vec.Size - startBit)
-		value = temp & vec.Data[start]
-		value >>= 64 - vec.Size - startBit
-
-	} else {
-		// Calculate new values for both affected positions.
-		// NOTE: This logic fails for a response that spans beyond 2 uint64.
-		// This is regulated by MAXVECSIZE.
-
-		temp := max >> (vec.Size - 64 + startBit)
-		value = temp & vec.Data[start]
-		value <<= vec.Size - 64 + startBit
-
-		temp = max << (64 - endBit - 1)
-		value2 := temp & vec.Data[end]
-		value2 >>= 64 - endBit - 1
-
-		value |= value2
-	}
-
-	return value == state, nil
+	return readBits(vec.Data, vec.Layout, index*vec.Size, vec.Size) == state, nil
 }
 
 // State is a method of BitVec that returns the state at a given index.
 // Returns an error if the index is out of bounds.
 func (vec *BitVec) State(index uint64) (uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
 	// Check for out of bounds index
 	if index >= vec.Count {
 		return 0, errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
 	}
 
-	// Get the start and end positions for the response state in the Data
-	start := (index * vec.Size) / 64
-	end := (((index + 1) * vec.Size) - 1) / 64
-
-	// Calculate the start and end bit positions
-	startBit := (index * vec.Size) % 64
-	endBit := (((index + 1) * vec.Size) - 1) % 64
-
-	var value uint64
-	max := vec.MaxState()
-
-	// If the response is contained within a single uint64 in Data
-	if start == end {
-		temp := max << (64 - vec.Size - startBit)
-		value = temp & vec.Data[start]
-		value >>= 64 - vec.Size - startBit
-
-	} else {
-		// Calculate new values for both affected positions.
-		// NOTE: This logic fails for a response that spans beyond 2 uint64.
-		// This is regulated by MAXVECSIZE.
-
-		temp := max >> (vec.Size - 64 + startBit)
-		value = temp & vec.Data[start]
-		value <<= vec.Size - 64 + startBit
-
-		temp = max << (64 - endBit - 1)
-		value2 := temp & vec.Data[end]
-		value2 >>= 64 - endBit - 1
-
-		value |= value2
-	}
-
-	return value, nil
+	return readBits(vec.Data, vec.Layout, index*vec.Size, vec.Size), nil
 }
 
 // Indexes is a method of BitVec that returns the slice of indexes matching the given state.
@@ -239,16 +181,10 @@ func (vec *BitVec) Indexes(state uint64) ([]uint64, error) {
 		return nil, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
 	}
 
-	// Iterate over the BitVec and check each index for
-	// equality with state and append index if equal
-	indexes := make([]uint64, 0)
-	for i := uint64(0); i < vec.Count; i++ {
-		// Error from Has() can be ignored because max state has already been checked
-		// and the count will never overflow because it is bounded by the loop condition.
-		if exists, _ := vec.Has(i, state); exists {
-			indexes = append(indexes, i)
-		}
-	}
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
 
-	return indexes, nil
+	match, fields := equalMatchers(vec.Size, state)
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, vec.Size, match, fields), nil
 }
@@ -0,0 +1,89 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// copySlots copies n responses of size bits from srcOff in src to dstOff in dst.
+// Responses are moved with word-level shifts if both layouts are the same and one by one otherwise.
+func copySlots(dst []uint64, dstLayout Layout, dstOff uint64, src []uint64, srcLayout Layout, srcOff, n, size uint64) {
+	if dstLayout == srcLayout {
+		copyBits(dst, dstOff*size, src, srcOff*size, n*size, dstLayout)
+		return
+	}
+
+	for i := uint64(0); i < n; i++ {
+		writeBits(dst, dstLayout, (dstOff+i)*size, size, readBits(src, srcLayout, (srcOff+i)*size, size))
+	}
+}
+
+// Concat is a constructor function for a BitVec holding the responses of all given vectors in order.
+// The BitVec has the Size and Layout of the first vector. Returns an error if no vectors are given
+// or if the Size of the vectors differ.
+func Concat(vecs ...*BitVec) (*BitVec, error) {
+	if len(vecs) == 0 {
+		return nil, errors.New("no bitvecs to concatenate")
+	}
+
+	// Error can be ignored because the Size and Layout of a BitVec are always valid
+	output, _ := NewBitVecWithLayout(0, vecs[0].Size, vecs[0].Layout)
+
+	for _, vec := range vecs {
+		if err := appendBitVec(output, vec); err != nil {
+			return nil, err
+		}
+	}
+
+	return output, nil
+}
+
+// appendBitVec appends the responses of vec to the output of Concat, reading the Count
+// of vec under its mutex so that it matches the copied responses.
+func appendBitVec(output, vec *BitVec) error {
+	// Acquire the mutex of the vector being copied
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check that all vectors have the same Size
+	if vec.Size != output.Size {
+		return errors.Errorf("bitvec sizes do not match (%v and %v)", output.Size, vec.Size)
+	}
+
+	count := output.Count + vec.Count
+	if words := (count*output.Size + 63) / 64; uint64(len(output.Data)) < words {
+		output.Data = append(output.Data, make([]uint64, words-uint64(len(output.Data)))...)
+	}
+
+	copySlots(output.Data, output.Layout, output.Count, vec.Data, vec.Layout, 0, vec.Count, vec.Size)
+	output.Count = count
+
+	return nil
+}
+
+// CopyRange copies n responses starting at srcOff in src to the responses starting at dstOff in dst.
+// The source and destination may be the same BitVec, even with overlapping ranges. Returns an error
+// if the Size of the vectors differ or if either range is not within the Count of its vector.
+func CopyRange(dst *BitVec, dstOff uint64, src *BitVec, srcOff, n uint64) error {
+	// Acquire both mutexes before checking the ranges against the Count of the vectors
+	unlock := lockPair(&dst.mu, &src.mu)
+	defer unlock()
+
+	// Check that both vectors have the same Size
+	if dst.Size != src.Size {
+		return errors.Errorf("bitvec sizes do not match (%v and %v)", dst.Size, src.Size)
+	}
+
+	// Check for ranges out of bounds
+	if srcOff > src.Count || n > src.Count-srcOff {
+		return errors.Errorf("range end too large for bitvec count (max: %v)", src.Count)
+	}
+
+	if dstOff > dst.Count || n > dst.Count-dstOff {
+		return errors.Errorf("range end too large for bitvec count (max: %v)", dst.Count)
+	}
+
+	copySlots(dst.Data, dst.Layout, dstOff, src.Data, src.Layout, srcOff, n, dst.Size)
+	dst.modified()
+
+	return nil
+}
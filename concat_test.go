@@ -0,0 +1,133 @@
+package bitvec
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomBitVec returns a BitVec with random states for tests.
+func randomBitVec(rng *rand.Rand, count, size uint64, layout Layout) *BitVec {
+	vec, _ := NewBitVecWithLayout(count, size, layout)
+	for i := uint64(0); i < count; i++ {
+		_ = vec.Set(i, rng.Uint64()&vec.MaxState())
+	}
+
+	return vec
+}
+
+func TestConcat(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	for _, size := range []uint64{1, 2, 5, 64} {
+		vecs := []*BitVec{
+			randomBitVec(rng, 37, size, MSBFirst),
+			randomBitVec(rng, 0, size, MSBFirst),
+			randomBitVec(rng, 130, size, LSBFirst),
+			randomBitVec(rng, 64, size, MSBFirst),
+		}
+
+		states := make([]uint64, 0)
+		for _, vec := range vecs {
+			states = vec.AppendStates(states)
+		}
+
+		output, err := Concat(vecs...)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, MSBFirst, output.Layout)
+
+		expected, _ := NewBitVecFromStates(size, states)
+		assert.Equal(t, expected.Data, output.Data)
+	}
+
+	_, err := Concat()
+	assert.EqualError(t, err, "no bitvecs to concatenate")
+
+	a, _ := NewBitVec(10, 2)
+	b, _ := NewBitVec(10, 3)
+	_, err = Concat(a, b)
+	assert.EqualError(t, err, "bitvec sizes do not match (2 and 3)")
+}
+
+func TestCopyRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 3, 7, 64} {
+			for n := 0; n < 30; n++ {
+				src := randomBitVec(rng, 200, size, MSBFirst)
+				dst := randomBitVec(rng, 150, size, layout)
+
+				length := uint64(rng.Intn(150))
+				srcOff, dstOff := uint64(rng.Intn(200-int(length)+1)), uint64(rng.Intn(150-int(length)+1))
+
+				srcStates, expected := src.ToStates(), dst.ToStates()
+				copy(expected[dstOff:], srcStates[srcOff:srcOff+length])
+
+				require.Nil(t, CopyRange(dst, dstOff, src, srcOff, length))
+				assert.Equal(t, expected, dst.ToStates())
+				assert.Equal(t, srcStates, src.ToStates())
+			}
+		}
+	}
+
+	// Overlapping ranges of the same vector are copied as if through a buffer
+	vec := randomBitVec(rng, 300, 5, MSBFirst)
+	for _, offsets := range [][2]uint64{{10, 73}, {73, 10}, {0, 1}, {1, 0}} {
+		expected := vec.ToStates()
+		copy(expected[offsets[0]:], append([]uint64(nil), expected[offsets[1]:offsets[1]+200]...))
+
+		require.Nil(t, CopyRange(vec, offsets[0], vec, offsets[1], 200))
+		assert.Equal(t, expected, vec.ToStates())
+	}
+
+	a, _ := NewBitVec(10, 2)
+	b, _ := NewBitVec(20, 2)
+	c, _ := NewBitVec(10, 3)
+
+	assert.EqualError(t, CopyRange(a, 0, c, 0, 1), "bitvec sizes do not match (2 and 3)")
+	assert.EqualError(t, CopyRange(a, 0, b, 15, 6), "range end too large for bitvec count (max: 20)")
+	assert.EqualError(t, CopyRange(a, 5, b, 0, 6), "range end too large for bitvec count (max: 10)")
+	assert.EqualError(t, CopyRange(a, 11, b, 0, 0), "range end too large for bitvec count (max: 10)")
+}
+
+func TestCopyRange_Concurrent(t *testing.T) {
+	a, _ := NewBitVec(33, 2)
+	b, _ := NewBitVec(33, 2)
+
+	// Deletes that shrink the Data by a word race with copies of the last responses,
+	// and copies in both directions race with each other
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 1000; n++ {
+			_ = a.DeleteAt(0)
+			_ = a.InsertAt(0, 1)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 1000; n++ {
+			_ = CopyRange(a, 1, b, 1, 32)
+			_, _ = Concat(a, b)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 1000; n++ {
+			_ = CopyRange(b, 1, a, 1, 32)
+		}
+	}()
+
+	wg.Wait()
+
+	assert.Equal(t, uint64(33), a.Count)
+	assert.Len(t, a.Data, 2)
+}
@@ -0,0 +1,262 @@
+package bitvec
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// slotWriter packs consecutive slots into a slice of words,
+// accumulating each word in a register before storing it.
+type slotWriter struct {
+	data   []uint64
+	layout Layout
+	size   uint64
+
+	// word is the index of the word being filled
+	word int
+	// acc holds the bits of the word being filled
+	acc uint64
+	// filled is the number of bits of acc already in use
+	filled uint64
+}
+
+// write appends a single state to the slotWriter.
+func (w *slotWriter) write(state uint64) {
+	if w.size == 0 {
+		return
+	}
+
+	// If the state fits into the word being filled
+	if w.filled+w.size <= 64 {
+		if w.layout == LSBFirst {
+			w.acc |= state << w.filled
+		} else {
+			w.acc |= state << (64 - w.filled - w.size)
+		}
+
+		w.filled += w.size
+		if w.filled == 64 {
+			w.flush()
+		}
+
+		return
+	}
+
+	// The state straddles two words, so store the part that
+	// fits and carry the remainder into the next word.
+	part := 64 - w.filled
+	rest := w.size - part
+
+	if w.layout == LSBFirst {
+		w.acc |= state << w.filled
+		w.flush()
+		w.acc, w.filled = state>>part, rest
+	} else {
+		w.acc |= state >> rest
+		w.flush()
+		w.acc, w.filled = state<<(64-rest), rest
+	}
+}
+
+// flush stores the word being filled and moves on to the next one.
+// It must be called once after the last write if any bits are pending.
+func (w *slotWriter) flush() {
+	if w.filled == 0 {
+		return
+	}
+
+	w.data[w.word] = w.acc
+	w.word++
+	w.acc, w.filled = 0, 0
+}
+
+// slotReader unpacks consecutive slots from a slice of words,
+// loading each word once into a register.
+type slotReader struct {
+	data   []uint64
+	layout Layout
+	size   uint64
+
+	// word is the index of the next word to load
+	word int
+	// acc holds the bits of the current word that are yet to be read
+	acc uint64
+	// avail is the number of bits of acc yet to be read
+	avail uint64
+}
+
+// read returns the next state from the slotReader.
+func (r *slotReader) read() uint64 {
+	if r.size == 0 {
+		return 0
+	}
+
+	// If the state is contained in the bits that are left
+	if r.avail >= r.size {
+		var state uint64
+		if r.layout == LSBFirst {
+			state = r.acc & bitMask(r.size)
+			r.acc >>= r.size
+		} else {
+			state = r.acc >> (64 - r.size)
+			r.acc <<= r.size
+		}
+
+		r.avail -= r.size
+		return state
+	}
+
+	// The state straddles into the next word, which is loaded
+	// and its leading bits combined with the ones that are left.
+	next := r.data[r.word]
+	r.word++
+	rest := r.size - r.avail
+
+	var state uint64
+	if r.layout == LSBFirst {
+		state = (r.acc | next<<r.avail) & bitMask(r.size)
+		r.acc = next >> rest
+	} else {
+		state = r.acc>>(64-r.size) | next>>(64-rest)
+		r.acc = next << rest
+	}
+
+	r.avail = 64 - rest
+	return state
+}
+
+// NewBitVecFromStates is a constructor function for BitVec that packs the given states.
+// The Count of the BitVec is the number of states and its Layout is MSBFirst.
+// Returns an error if Size is greater than MAXVECSIZE or if any state exceeds the maximum for the Size.
+func NewBitVecFromStates(size uint64, states []uint64) (*BitVec, error) {
+	vec, err := NewBitVec(uint64(len(states)), size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for state values too large for BitVec
+	for _, state := range states {
+		if state > vec.MaxState() {
+			return nil, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+		}
+	}
+
+	writer := slotWriter{data: vec.Data, layout: vec.Layout, size: vec.Size}
+	for _, state := range states {
+		writer.write(state)
+	}
+
+	writer.flush()
+	return vec, nil
+}
+
+// FromBools is a constructor function for a BitVec with a Size of 1
+// that has the state 1 for every true value and 0 for every false value.
+func FromBools(bools []bool) *BitVec {
+	// Error can be ignored because a Size of 1 is always allowed
+	vec, _ := NewBitVec(uint64(len(bools)), 1)
+
+	for i, value := range bools {
+		if value {
+			vec.Data[i/64] |= 1 << (63 - i%64)
+		}
+	}
+
+	return vec
+}
+
+// appendStates appends the states of count slots of size bits in the data to dst and returns the extended slice.
+func appendStates(dst []uint64, data []uint64, layout Layout, count, size uint64) []uint64 {
+	reader := slotReader{data: data, layout: layout, size: size}
+	for i := uint64(0); i < count; i++ {
+		dst = append(dst, reader.read())
+	}
+
+	return dst
+}
+
+// ToStates is a method of BitVec that returns the states of all responses in order.
+func (vec *BitVec) ToStates() []uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return appendStates(make([]uint64, 0, vec.Count), vec.Data, vec.Layout, vec.Count, vec.Size)
+}
+
+// AppendStates is a method of BitVec that appends the states
+// of all responses in order to dst and returns the extended slice.
+func (vec *BitVec) AppendStates(dst []uint64) []uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return appendStates(dst, vec.Data, vec.Layout, vec.Count, vec.Size)
+}
+
+// Bytes is a method of BitVec that returns the packed representation of the responses.
+// The words of Data are encoded big-endian for MSBFirst and little-endian for LSBFirst,
+// such that the bytes hold the responses in order, and truncated to the bytes in use.
+func (vec *BitVec) Bytes() []byte {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	output := make([]byte, len(vec.Data)*8)
+	for i, word := range vec.Data {
+		if vec.Layout == LSBFirst {
+			binary.LittleEndian.PutUint64(output[i*8:], word)
+		} else {
+			binary.BigEndian.PutUint64(output[i*8:], word)
+		}
+	}
+
+	return output[:(vec.Count*vec.Size+7)/8]
+}
+
+// FromBytes is a constructor function for a BitVec with the
+// MSBFirst layout from the packed representation returned by Bytes.
+// Returns an error if Size is greater than MAXVECSIZE or if the length of data does not match.
+func FromBytes(count, size uint64, data []byte) (*BitVec, error) {
+	return FromBytesWithLayout(count, size, MSBFirst, data)
+}
+
+// FromBytesWithLayout is a constructor function for a BitVec with the
+// given Layout from the packed representation returned by Bytes.
+// Returns an error if Size is greater than MAXVECSIZE, if the Layout is unknown or if the length of data does not match.
+func FromBytesWithLayout(count, size uint64, layout Layout, data []byte) (*BitVec, error) {
+	vec, err := NewBitVecWithLayout(count, size, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that the data holds exactly the bytes in use
+	if expected := (count*size + 7) / 8; uint64(len(data)) != expected {
+		return nil, errors.Errorf("byte length does not match bitvec count and size (want: %v)", expected)
+	}
+
+	// Pad the data to a whole number of words
+	padded := make([]byte, len(vec.Data)*8)
+	copy(padded, data)
+
+	for i := range vec.Data {
+		if layout == LSBFirst {
+			vec.Data[i] = binary.LittleEndian.Uint64(padded[i*8:])
+		} else {
+			vec.Data[i] = binary.BigEndian.Uint64(padded[i*8:])
+		}
+	}
+
+	// Clear any bits beyond the last response
+	if used := (count * size) % 64; used != 0 {
+		last := len(vec.Data) - 1
+		if layout == LSBFirst {
+			vec.Data[last] &= bitMask(used)
+		} else {
+			vec.Data[last] &^= bitMask(64 - used)
+		}
+	}
+
+	return vec, nil
+}
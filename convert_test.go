@@ -0,0 +1,117 @@
+package bitvec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBitVecFromStates(t *testing.T) {
+	tests := []struct {
+		size   uint64
+		states []uint64
+		data   []uint64
+		err    string
+	}{
+		{2, []uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3}, []uint64{12}, ""},
+		{8, []uint64{0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 2}, []uint64{12884901888, 2199023255552}, ""},
+		{64, []uint64{5, 1<<64 - 1}, []uint64{5, 1<<64 - 1}, ""},
+		{0, []uint64{0, 0}, []uint64{}, ""},
+		{4, []uint64{1, 16}, nil, "state too large for bitvec state (max: 15)"},
+		{70, []uint64{1}, nil, "state size greater 64 not allowed"},
+	}
+
+	for _, test := range tests {
+		vec, err := NewBitVecFromStates(test.size, test.states)
+
+		if test.err == "" {
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, test.data, vec.Data)
+			assert.Equal(t, test.states, vec.ToStates())
+		} else {
+			assert.EqualError(t, err, test.err)
+			assert.Nil(t, vec)
+		}
+	}
+}
+
+func TestBitVec_ToStates(t *testing.T) {
+	states := make([]uint64, 100)
+	for i := range states {
+		states[i] = uint64(i*7) % 32
+	}
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		vec, err := NewBitVecWithLayout(100, 5, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		for i, state := range states {
+			require.Nil(t, vec.Set(uint64(i), state))
+		}
+
+		assert.Equal(t, states, vec.ToStates())
+		assert.Equal(t, append([]uint64{42}, states...), vec.AppendStates([]uint64{42}))
+	}
+}
+
+func TestFromBools(t *testing.T) {
+	bools := make([]bool, 70)
+	bools[0], bools[63], bools[64], bools[69] = true, true, true, true
+
+	vec := FromBools(bools)
+	assert.Equal(t, uint64(70), vec.Count)
+	assert.Equal(t, uint64(1), vec.Size)
+	assert.Equal(t, []uint64{9223372036854775809, 9223372036854775808 | 288230376151711744}, vec.Data)
+
+	indexes, err := vec.Indexes(1)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0, 63, 64, 69}, indexes)
+}
+
+func TestBitVec_Bytes(t *testing.T) {
+	tests := []struct {
+		bitvec *BitVec
+		output []byte
+	}{
+		{
+			&BitVec{Count: 6, Size: 2, Data: []uint64{0xE400000000000000}},
+			[]byte{0xE4, 0x00},
+		},
+		{
+			&BitVec{Count: 6, Size: 2, Layout: LSBFirst, Data: []uint64{0x01B}},
+			[]byte{0x1B, 0x00},
+		},
+		{
+			&BitVec{Count: 3, Size: 30, Data: []uint64{0x0123456789ABCDEF, 0xFEDCBA9876543210}},
+			[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF, 0xFE, 0xDC, 0xBA, 0x98},
+		},
+	}
+
+	for _, test := range tests {
+		output := test.bitvec.Bytes()
+		assert.Equal(t, test.output, output)
+
+		vec, err := FromBytesWithLayout(test.bitvec.Count, test.bitvec.Size, test.bitvec.Layout, output)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, test.bitvec.ToStates(), vec.ToStates())
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	vec, err := FromBytes(4, 3, []byte{0xFF, 0xFF})
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0xFFF0000000000000}, vec.Data)
+
+	vec, err = FromBytesWithLayout(4, 3, LSBFirst, []byte{0xFF, 0xFF})
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0xFFF}, vec.Data)
+
+	vec, err = FromBytes(4, 3, []byte{0xFF})
+	assert.EqualError(t, err, "byte length does not match bitvec count and size (want: 2)")
+	assert.Nil(t, vec)
+
+	vec, err = FromBytes(4, 65, []byte{0xFF})
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+	assert.Nil(t, vec)
+}
@@ -0,0 +1,157 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// OverflowMode is the behaviour of Add when a state would leave the range from 0 to the maximum state.
+type OverflowMode uint8
+
+const (
+	// OverflowWrap wraps the state around modulo the number of states, like unsigned integer arithmetic.
+	OverflowWrap OverflowMode = iota
+
+	// OverflowSaturate clamps the state to 0 or the maximum state.
+	OverflowSaturate
+
+	// OverflowFail leaves the state unchanged and returns an error.
+	OverflowFail
+)
+
+// String implements the Stringer interface for OverflowMode
+func (mode OverflowMode) String() string {
+	switch mode {
+	case OverflowWrap:
+		return "wrap"
+	case OverflowSaturate:
+		return "saturate"
+	case OverflowFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+// validate returns an error if the OverflowMode is not one of the known modes.
+func (mode OverflowMode) validate() error {
+	if mode > OverflowFail {
+		return errors.Errorf("unknown overflow mode: %d", mode)
+	}
+
+	return nil
+}
+
+// addState returns the state plus delta for states up to max according to the overflow mode.
+// Returns an error if the result leaves the range of states and the mode is OverflowFail.
+func addState(state uint64, delta int64, max uint64, mode OverflowMode, name string) (uint64, error) {
+	// Wrapping is unsigned arithmetic modulo the number of states, which divides 2^64
+	if mode == OverflowWrap {
+		return (state + uint64(delta)) & max, nil
+	}
+
+	if delta >= 0 {
+		if uint64(delta) <= max-state {
+			return state + uint64(delta), nil
+		}
+
+		if mode == OverflowSaturate {
+			return max, nil
+		}
+
+		return 0, errors.Errorf("counter overflow for %v state (max: %v)", name, max)
+	}
+
+	// Negate without overflowing for the smallest int64
+	magnitude := uint64(-(delta + 1)) + 1
+	if magnitude <= state {
+		return state - magnitude, nil
+	}
+
+	if mode == OverflowSaturate {
+		return 0, nil
+	}
+
+	return 0, errors.Errorf("counter underflow for %v state (min: 0)", name)
+}
+
+// Add is a method of BitVec that atomically adds delta to the state at a given index, treating the response
+// as an unsigned counter, and returns the new state. A result beyond 0 or the maximum for the BitVec is
+// handled according to the mode. Returns an error if the index is out of bounds, if the mode is unknown
+// or if the result is out of range with OverflowFail, in which case the state is unchanged.
+func (vec *BitVec) Add(index uint64, delta int64, mode OverflowMode) (uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check if given OverflowMode is known
+	if err := mode.validate(); err != nil {
+		return 0, err
+	}
+
+	pos := index * vec.Size
+	state, err := addState(readBits(vec.Data, vec.Layout, pos, vec.Size), delta, vec.MaxState(), mode, "bitvec")
+	if err != nil {
+		return 0, err
+	}
+
+	writeBits(vec.Data, vec.Layout, pos, vec.Size, state)
+	vec.modified()
+
+	return state, nil
+}
+
+// Increment is a method of BitVec that atomically adds 1 to the state at a given index, like Add.
+func (vec *BitVec) Increment(index uint64, mode OverflowMode) (uint64, error) {
+	return vec.Add(index, 1, mode)
+}
+
+// Decrement is a method of BitVec that atomically subtracts 1 from the state at a given index, like Add.
+func (vec *BitVec) Decrement(index uint64, mode OverflowMode) (uint64, error) {
+	return vec.Add(index, -1, mode)
+}
+
+// Add is a method of DiBit that atomically adds delta to the state at a given index, treating the response
+// as an unsigned counter, and returns the new state. A result beyond 0 or the maximum for the DiBit is
+// handled according to the mode. Returns an error if the index is out of bounds, if the mode is unknown
+// or if the result is out of range with OverflowFail, in which case the state is unchanged.
+func (vec *DiBit) Add(index uint64, delta int64, mode OverflowMode) (uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	// Check if given OverflowMode is known
+	if err := mode.validate(); err != nil {
+		return 0, err
+	}
+
+	pos := index * DIBITSIZE
+	state, err := addState(readBits(vec.Data, vec.Layout, pos, DIBITSIZE), delta, vec.MaxState(), mode, "dibit")
+	if err != nil {
+		return 0, err
+	}
+
+	writeBits(vec.Data, vec.Layout, pos, DIBITSIZE, state)
+	vec.modified()
+
+	return state, nil
+}
+
+// Increment is a method of DiBit that atomically adds 1 to the state at a given index, like Add.
+func (vec *DiBit) Increment(index uint64, mode OverflowMode) (uint64, error) {
+	return vec.Add(index, 1, mode)
+}
+
+// Decrement is a method of DiBit that atomically subtracts 1 from the state at a given index, like Add.
+func (vec *DiBit) Decrement(index uint64, mode OverflowMode) (uint64, error) {
+	return vec.Add(index, -1, mode)
+}
@@ -0,0 +1,135 @@
+package bitvec
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverflowMode_String(t *testing.T) {
+	assert.Equal(t, "wrap", OverflowWrap.String())
+	assert.Equal(t, "saturate", OverflowSaturate.String())
+	assert.Equal(t, "fail", OverflowFail.String())
+	assert.Equal(t, "unknown", OverflowMode(9).String())
+}
+
+func TestBitVec_Add(t *testing.T) {
+	tests := []struct {
+		size, initial uint64
+		delta         int64
+		mode          OverflowMode
+		output        uint64
+		err           string
+	}{
+		{3, 2, 3, OverflowFail, 5, ""},
+		{3, 5, -5, OverflowFail, 0, ""},
+		{3, 6, 2, OverflowWrap, 0, ""},
+		{3, 1, -3, OverflowWrap, 6, ""},
+		{3, 1, math.MinInt64, OverflowWrap, 1, ""},
+		{3, 6, 2, OverflowSaturate, 7, ""},
+		{3, 1, -3, OverflowSaturate, 0, ""},
+		{3, 1, math.MinInt64, OverflowSaturate, 0, ""},
+		{3, 6, math.MaxInt64, OverflowSaturate, 7, ""},
+		{3, 6, 2, OverflowFail, 6, "counter overflow for bitvec state (max: 7)"},
+		{3, 1, -3, OverflowFail, 1, "counter underflow for bitvec state (min: 0)"},
+		{64, math.MaxUint64 - 1, 1, OverflowFail, math.MaxUint64, ""},
+		{64, math.MaxUint64, 1, OverflowWrap, 0, ""},
+		{64, math.MaxUint64, 1, OverflowSaturate, math.MaxUint64, ""},
+		{64, 0, math.MinInt64, OverflowWrap, 1 << 63, ""},
+		{64, 1 << 63, math.MinInt64, OverflowFail, 0, ""},
+		{13, 100, 3, OverflowMode(5), 100, "unknown overflow mode: 5"},
+	}
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, test := range tests {
+			vec, _ := NewBitVecWithLayout(10, test.size, layout)
+			require.Nil(t, vec.Set(5, test.initial))
+			require.Nil(t, vec.Set(4, vec.MaxState()))
+			require.Nil(t, vec.Set(6, vec.MaxState()))
+
+			state, err := vec.Add(5, test.delta, test.mode)
+			if test.err == "" {
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, test.output, state)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+
+			// The neighbouring responses are unchanged
+			current, _ := vec.State(5)
+			assert.Equal(t, test.output, current)
+
+			for _, index := range []uint64{4, 6} {
+				neighbour, _ := vec.State(index)
+				assert.Equal(t, vec.MaxState(), neighbour)
+			}
+		}
+	}
+
+	vec, _ := NewBitVec(10, 2)
+	_, err := vec.Add(10, 1, OverflowWrap)
+	assert.EqualError(t, err, "index too large for bitvec count (max: 10)")
+
+	state, err := vec.Increment(3, OverflowFail)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(1), state)
+
+	state, err = vec.Decrement(3, OverflowFail)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(0), state)
+
+	_, err = vec.Decrement(3, OverflowFail)
+	assert.EqualError(t, err, "counter underflow for bitvec state (min: 0)")
+}
+
+func TestBitVec_Add_Concurrent(t *testing.T) {
+	vec, _ := NewBitVec(3, 16)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				_, _ = vec.Increment(1, OverflowFail)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	state, _ := vec.State(1)
+	assert.Equal(t, uint64(8000), state)
+}
+
+func TestDiBit_Add(t *testing.T) {
+	vec := NewDiBit(40)
+
+	state, err := vec.Add(32, 2, OverflowFail)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(2), state)
+
+	state, err = vec.Increment(32, OverflowWrap)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(3), state)
+
+	state, err = vec.Increment(32, OverflowWrap)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(0), state)
+
+	state, err = vec.Decrement(32, OverflowSaturate)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(0), state)
+
+	_, err = vec.Add(32, 4, OverflowFail)
+	assert.EqualError(t, err, "counter overflow for dibit state (max: 3)")
+
+	_, err = vec.Add(40, 1, OverflowFail)
+	assert.EqualError(t, err, "index too large for dibit count (max: 40)")
+
+	_, err = vec.Add(0, 1, OverflowMode(3))
+	assert.EqualError(t, err, "unknown overflow mode: 3")
+}
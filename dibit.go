@@ -18,11 +18,16 @@ type DiBit struct {
 
 	// Count is the number of responses
 	Count uint64
+	// Layout is the order in which responses are packed into Data
+	Layout Layout
 	// Data stores the responses according to their indices
 	Data []uint64
+
+	// rank is the optional auxiliary rank directory over Data
+	rank *rankDirectory
 }
 
-// NewDiBit is a constructor function for DiBit.
+// NewDiBit is a constructor function for DiBit with the default MSBFirst layout.
 func NewDiBit(count uint64) *DiBit {
 	return &DiBit{
 		mu: sync.Mutex{}, Count: count,
@@ -30,6 +35,20 @@ func NewDiBit(count uint64) *DiBit {
 	}
 }
 
+// NewDiBitWithLayout is a constructor function for DiBit with the given Layout.
+// Returns an error if the Layout is unknown.
+func NewDiBitWithLayout(count uint64, layout Layout) (*DiBit, error) {
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	vec := NewDiBit(count)
+	vec.Layout = layout
+
+	return vec, nil
+}
+
 // String implements the Stringer interface for DiBit
 func (vec *DiBit) String() string {
 	return fmt.Sprintf("[%v] %064b", vec.Count, vec.Data)
@@ -41,9 +60,40 @@ func (vec *DiBit) MaxState() uint64 {
 	return 1<<DIBITSIZE - 1
 }
 
+// modified marks any auxiliary indexes over the Data of the DiBit as out of date.
+// It must be called with the mutex held by every method that writes to the Data.
+func (vec *DiBit) modified() {
+	if vec.rank != nil {
+		vec.rank.stale = true
+	}
+}
+
+// Relayout is a method of DiBit that repacks the responses into the given Layout.
+// The states of all responses are preserved. Returns an error if the Layout is unknown.
+func (vec *DiBit) Relayout(layout Layout) error {
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return err
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	copy(vec.Data, relayout(vec.Data, vec.Count, DIBITSIZE, vec.Layout, layout))
+	vec.Layout = layout
+	vec.modified()
+
+	return nil
+}
+
 // Set is a method of DiBit that sets a given state at given index.
 // Returns an error if the index is out of bounds or if the state value exceeds the maximum for the DiBit.
 func (vec *DiBit) Set(index, state uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
 	// Check for out of bounds index
 	if index >= vec.Count {
 		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
@@ -54,18 +104,10 @@ func (vec *DiBit) Set(index, state uint64) error {
 		return errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
 	}
 
-	// Acquire the mutex
-	vec.mu.Lock()
-	defer vec.mu.Unlock()
-
-	// Get the start position for the response state in the Data
-	start := (index * DIBITSIZE) / 64
-
-	// Calculate the start bit position
-	startBit := (index * DIBITSIZE) % 64
-
-	temp := state << (64 - DIBITSIZE - startBit)
-	vec.Data[start] |= temp
+	// Merge the state into the bits of the response at its position in the Data
+	pos := index * DIBITSIZE
+	writeBits(vec.Data, vec.Layout, pos, DIBITSIZE, readBits(vec.Data, vec.Layout, pos, DIBITSIZE)|state)
+	vec.modified()
 
 	return nil
 }
@@ -73,25 +115,18 @@ func (vec *DiBit) Set(index, state uint64) error {
 // Unset is a method of DiBit that unsets the state for a given index.
 // Returns an error index is out of bounds.
 func (vec *DiBit) Unset(index uint64) error {
-	// Check for out of bounds index
-	if index >= vec.Count {
-		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
-	}
-
 	// Acquire the mutex
 	vec.mu.Lock()
 	defer vec.mu.Unlock()
 
-	// Get the start position for the response state in the Data
-	start := (index * DIBITSIZE) / 64
-
-	// Calculate the start bit position
-	startBit := (index * DIBITSIZE) % 64
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
 
-	max := uint64(1<<64 - 1)
-	temp := vec.MaxState() << (64 - DIBITSIZE - startBit)
-	temp ^= max
-	vec.Data[start] &= temp
+	// Clear the bits of the response at its position in the Data
+	writeBits(vec.Data, vec.Layout, index*DIBITSIZE, DIBITSIZE, 0)
+	vec.modified()
 
 	return nil
 }
@@ -99,6 +134,10 @@ func (vec *DiBit) Unset(index uint64) error {
 // Has is a method of DiBit that checks whether the state at a given index matches the given state.
 // Returns an error if the index is out of bounds or if the state value exceeds the maximum for the DiBit.
 func (vec *DiBit) Has(index, state uint64) (bool, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
 	// Check for out of bounds index
 	if index >= vec.Count {
 		return false, errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
@@ -109,42 +148,22 @@ func (vec *DiBit) Has(index, state uint64) (bool, error) {
 		return false, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
 	}
 
-	// Get the start position for the response state in the Data
-	start := (index * DIBITSIZE) / 64
-
-	// Calculate the start bit position
-	startBit := (index * DIBITSIZE) % 64
-
-	var value uint64
-
-	temp := vec.MaxState() << (64 - DIBITSIZE - startBit)
-	value = temp & vec.Data[start]
-	value >>= 64 - DIBITSIZE - startBit
-
-	return value == state, nil
+	return readBits(vec.Data, vec.Layout, index*DIBITSIZE, DIBITSIZE) == state, nil
 }
 
 // State is a method of DiBit that returns the state at a given index.
 // Returns an error if the index is out of bounds.
 func (vec *DiBit) State(index uint64) (uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
 	// Check for out of bounds index
 	if index >= vec.Count {
 		return 0, errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
 	}
 
-	// Get the start position for the response state in the Data
-	start := (index * DIBITSIZE) / 64
-
-	// Calculate the start bit position
-	startBit := (index * DIBITSIZE) % 64
-
-	var value uint64
-
-	temp := vec.MaxState() << (64 - DIBITSIZE - startBit)
-	value = temp & vec.Data[start]
-	value >>= 64 - DIBITSIZE - startBit
-
-	return value, nil
+	return readBits(vec.Data, vec.Layout, index*DIBITSIZE, DIBITSIZE), nil
 }
 
 // Indexes is a method of DiBit that returns the slice of indexes matching the given state.
@@ -155,16 +174,10 @@ func (vec *DiBit) Indexes(state uint64) ([]uint64, error) {
 		return nil, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
 	}
 
-	// Iterate over the DiBit and check each index for
-	// equality with state and append index if equal
-	indexes := make([]uint64, 0)
-	for i := uint64(0); i < vec.Count; i++ {
-		// Error from Has() can be ignored because max state has already been checked
-		// and the count will never overflow because it is bounded by the loop condition.
-		if exists, _ := vec.Has(i, state); exists {
-			indexes = append(indexes, i)
-		}
-	}
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
 
-	return indexes, nil
+	match, fields := equalMatchers(DIBITSIZE, state)
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, DIBITSIZE, match, fields), nil
 }
@@ -0,0 +1,85 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MAXDENSEGROUPSIZE is the maximum Size of a BitVec for GroupByStateDense,
+// which allocates a slice of indexes for every possible state.
+const MAXDENSEGROUPSIZE = 16
+
+// groupByState partitions the indexes of count slots of size bits in the data by their state in one pass.
+func groupByState(data []uint64, layout Layout, count, size uint64) map[uint64][]uint64 {
+	groups := make(map[uint64][]uint64)
+
+	reader := slotReader{data: data, layout: layout, size: size}
+	for i := uint64(0); i < count; i++ {
+		state := reader.read()
+		groups[state] = append(groups[state], i)
+	}
+
+	return groups
+}
+
+// groupByStateDense partitions the indexes of count slots of size bits in the data by their state in one pass,
+// into a slice with the indexes of every state from 0 to the maximum for the size.
+func groupByStateDense(data []uint64, layout Layout, count, size uint64) [][]uint64 {
+	groups := make([][]uint64, 1<<size)
+	for state := range groups {
+		groups[state] = make([]uint64, 0)
+	}
+
+	reader := slotReader{data: data, layout: layout, size: size}
+	for i := uint64(0); i < count; i++ {
+		state := reader.read()
+		groups[state] = append(groups[state], i)
+	}
+
+	return groups
+}
+
+// GroupByState is a method of BitVec that returns the indexes of all responses grouped by their state,
+// collected in a single pass over the Data. Only the states that occur are keys of the map.
+func (vec *BitVec) GroupByState() map[uint64][]uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return groupByState(vec.Data, vec.Layout, vec.Count, vec.Size)
+}
+
+// GroupByStateDense is a method of BitVec that returns the indexes of all responses grouped by their state,
+// collected in a single pass over the Data, as a slice with the (possibly empty) indexes of every state
+// from 0 to the maximum for the BitVec. Returns an error if the Size is greater than MAXDENSEGROUPSIZE.
+func (vec *BitVec) GroupByStateDense() ([][]uint64, error) {
+	// Check for a Size with too many states for a slice
+	if vec.Size > MAXDENSEGROUPSIZE {
+		return nil, errors.Errorf("bitvec size too large for dense groups (max: %v)", MAXDENSEGROUPSIZE)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return groupByStateDense(vec.Data, vec.Layout, vec.Count, vec.Size), nil
+}
+
+// GroupByState is a method of DiBit that returns the indexes of all responses grouped by their state,
+// collected in a single pass over the Data. Only the states that occur are keys of the map.
+func (vec *DiBit) GroupByState() map[uint64][]uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return groupByState(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+}
+
+// GroupByStateDense is a method of DiBit that returns the indexes of all responses grouped by their state,
+// collected in a single pass over the Data, as a slice with the (possibly empty) indexes of every state.
+func (vec *DiBit) GroupByStateDense() [][]uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return groupByStateDense(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+}
@@ -0,0 +1,57 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_GroupByState(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 3, 5} {
+			vec := randomBitVec(rng, 250, size, layout)
+
+			groups := vec.GroupByState()
+			dense, err := vec.GroupByStateDense()
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, int(vec.MaxState()+1), len(dense))
+
+			total := 0
+			for state := uint64(0); state <= vec.MaxState(); state++ {
+				expected, _ := vec.Indexes(state)
+				assert.Equal(t, expected, dense[state])
+
+				if len(expected) == 0 {
+					assert.NotContains(t, groups, state)
+				} else {
+					assert.Equal(t, expected, groups[state])
+				}
+
+				total += len(expected)
+			}
+
+			assert.Equal(t, 250, total)
+		}
+	}
+
+	vec, _ := NewBitVec(4, 17)
+	require.Nil(t, vec.Set(2, 100000))
+	assert.Equal(t, map[uint64][]uint64{0: {0, 1, 3}, 100000: {2}}, vec.GroupByState())
+
+	_, err := vec.GroupByStateDense()
+	assert.EqualError(t, err, "bitvec size too large for dense groups (max: 16)")
+}
+
+func TestDiBit_GroupByState(t *testing.T) {
+	vec := NewDiBit(6)
+	for i, state := range []uint64{1, 3, 1, 0, 3, 3} {
+		require.Nil(t, vec.Set(uint64(i), state))
+	}
+
+	assert.Equal(t, map[uint64][]uint64{0: {3}, 1: {0, 2}, 3: {1, 4, 5}}, vec.GroupByState())
+	assert.Equal(t, [][]uint64{{3}, {0, 2}, {}, {1, 4, 5}}, vec.GroupByStateDense())
+}
@@ -0,0 +1,173 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Layout is the order in which slots are packed into the words of Data.
+type Layout uint8
+
+const (
+	// MSBFirst packs slot 0 into the most significant bits of Data[0],
+	// with each following slot occupying the next lower bits.
+	// This is the default layout for all vectors.
+	MSBFirst Layout = iota
+
+	// LSBFirst packs slot 0 into the least significant bits of Data[0],
+	// with each following slot occupying the next higher bits.
+	// This matches the layout used by most other bitset implementations.
+	LSBFirst
+)
+
+// String implements the Stringer interface for Layout
+func (layout Layout) String() string {
+	switch layout {
+	case MSBFirst:
+		return "msb-first"
+	case LSBFirst:
+		return "lsb-first"
+	default:
+		return "unknown"
+	}
+}
+
+// validate returns an error if the Layout is not one of the known layouts.
+func (layout Layout) validate() error {
+	if layout != MSBFirst && layout != LSBFirst {
+		return errors.Errorf("unknown bit layout: %d", layout)
+	}
+
+	return nil
+}
+
+// bitMask returns a mask with the n least significant bits set.
+func bitMask(n uint64) uint64 {
+	if n >= 64 {
+		return 1<<64 - 1
+	}
+
+	return 1<<n - 1
+}
+
+// readBits returns the n bits (at most 64) starting at bit position pos of the data.
+// Bit positions are counted from the start of slot 0 in the given layout.
+// For MSBFirst the bit at pos becomes the most significant bit of the returned
+// value and for LSBFirst it becomes the least significant bit, so that a single
+// slot is always returned as its plain state value.
+func readBits(data []uint64, layout Layout, pos, n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	// Get the word and the bit offset within it for the position
+	word, offset := pos/64, pos%64
+
+	if layout == LSBFirst {
+		// If the bits are contained within a single word
+		if offset+n <= 64 {
+			return (data[word] >> offset) & bitMask(n)
+		}
+
+		// Bits straddle two words: the low part comes from the
+		// top of the first word and the high part from the next.
+		low := 64 - offset
+		return data[word]>>offset | (data[word+1]&bitMask(n-low))<<low
+	}
+
+	// If the bits are contained within a single word
+	if offset+n <= 64 {
+		return (data[word] << offset) >> (64 - n)
+	}
+
+	// Bits straddle two words: the high part comes from the
+	// bottom of the first word and the low part from the next.
+	return (data[word]<<offset)>>(64-n) | data[word+1]>>(128-n-offset)
+}
+
+// writeBits overwrites the n bits (at most 64) starting at bit position pos
+// of the data with the n least significant bits of value.
+// It is the inverse of readBits for the same layout.
+func writeBits(data []uint64, layout Layout, pos, n, value uint64) {
+	if n == 0 {
+		return
+	}
+
+	// Get the word and the bit offset within it for the position
+	word, offset := pos/64, pos%64
+	value &= bitMask(n)
+
+	if layout == LSBFirst {
+		// If the bits are contained within a single word
+		if offset+n <= 64 {
+			mask := bitMask(n) << offset
+			data[word] = data[word]&^mask | value<<offset
+			return
+		}
+
+		// Bits straddle two words
+		low := 64 - offset
+		data[word] = data[word]&^(bitMask(low)<<offset) | value<<offset
+		data[word+1] = data[word+1]&^bitMask(n-low) | value>>low
+		return
+	}
+
+	// If the bits are contained within a single word
+	if offset+n <= 64 {
+		shift := 64 - offset - n
+		mask := bitMask(n) << shift
+		data[word] = data[word]&^mask | value<<shift
+		return
+	}
+
+	// Bits straddle two words
+	high := 64 - offset
+	shift := 64 - (n - high)
+	data[word] = data[word]&^bitMask(high) | value>>(n-high)
+	data[word+1] = data[word+1]&^(bitMask(n-high)<<shift) | value<<shift
+}
+
+// relayout returns a copy of data, holding count slots of size bits
+// packed in the from layout, repacked into the to layout.
+func relayout(data []uint64, count, size uint64, from, to Layout) []uint64 {
+	output := make([]uint64, len(data))
+	if from == to {
+		copy(output, data)
+		return output
+	}
+
+	for i := uint64(0); i < count; i++ {
+		writeBits(output, to, i*size, size, readBits(data, from, i*size, size))
+	}
+
+	return output
+}
+
+// copyBits copies the n bits starting at bit position srcPos of src to bit position dstPos of dst,
+// both packed in the given layout, 64 bits at a time. The ranges may overlap within the same data,
+// in which case the bits are copied as if through an intermediate buffer.
+func copyBits(dst []uint64, dstPos uint64, src []uint64, srcPos, n uint64, layout Layout) {
+	// Copy backwards if the destination follows the source, so that
+	// overlapping bits are read before they are overwritten
+	if dstPos > srcPos {
+		for n > 0 {
+			chunk := n % 64
+			if chunk == 0 {
+				chunk = 64
+			}
+
+			n -= chunk
+			writeBits(dst, layout, dstPos+n, chunk, readBits(src, layout, srcPos+n, chunk))
+		}
+
+		return
+	}
+
+	for done := uint64(0); done < n; done += 64 {
+		chunk := n - done
+		if chunk > 64 {
+			chunk = 64
+		}
+
+		writeBits(dst, layout, dstPos+done, chunk, readBits(src, layout, srcPos+done, chunk))
+	}
+}
@@ -0,0 +1,184 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout_String(t *testing.T) {
+	assert.Equal(t, "msb-first", MSBFirst.String())
+	assert.Equal(t, "lsb-first", LSBFirst.String())
+	assert.Equal(t, "unknown", Layout(7).String())
+}
+
+func TestReadWriteBits(t *testing.T) {
+	tests := []struct {
+		layout      Layout
+		pos, n, val uint64
+		output      []uint64
+	}{
+		{MSBFirst, 0, 2, 3, []uint64{13835058055282163712, 0}},
+		{MSBFirst, 62, 4, 9, []uint64{2, 4611686018427387904}},
+		{MSBFirst, 0, 64, 12345, []uint64{12345, 0}},
+		{MSBFirst, 32, 64, 1<<64 - 1, []uint64{4294967295, 18446744069414584320}},
+		{LSBFirst, 0, 2, 3, []uint64{3, 0}},
+		{LSBFirst, 62, 4, 9, []uint64{4611686018427387904, 2}},
+		{LSBFirst, 64, 64, 12345, []uint64{0, 12345}},
+		{LSBFirst, 32, 64, 1<<64 - 1, []uint64{18446744069414584320, 4294967295}},
+	}
+
+	for _, test := range tests {
+		data := make([]uint64, 2)
+		writeBits(data, test.layout, test.pos, test.n, test.val)
+		assert.Equal(t, test.output, data)
+		assert.Equal(t, test.val, readBits(data, test.layout, test.pos, test.n))
+
+		// Overwriting with zero must restore the empty data
+		writeBits(data, test.layout, test.pos, test.n, 0)
+		assert.Equal(t, []uint64{0, 0}, data)
+	}
+}
+
+func TestNewBitVecWithLayout(t *testing.T) {
+	vec, err := NewBitVecWithLayout(10, 10, LSBFirst)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, LSBFirst, vec.Layout)
+	assert.Equal(t, []uint64{0, 0}, vec.Data)
+
+	vec, err = NewBitVecWithLayout(10, 10, Layout(3))
+	assert.EqualError(t, err, "unknown bit layout: 3")
+	assert.Nil(t, vec)
+
+	dibit, err := NewDiBitWithLayout(40, LSBFirst)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, LSBFirst, dibit.Layout)
+	assert.Equal(t, []uint64{0, 0}, dibit.Data)
+
+	dibit, err = NewDiBitWithLayout(40, Layout(3))
+	assert.EqualError(t, err, "unknown bit layout: 3")
+	assert.Nil(t, dibit)
+}
+
+func TestBitVec_LSBFirst(t *testing.T) {
+	tests := []struct {
+		bitvec   *BitVec
+		idx, val uint64
+		output   []uint64
+	}{
+		{
+			&BitVec{Count: 32, Size: 2, Layout: LSBFirst, Data: []uint64{0}},
+			30, 3, []uint64{3458764513820540928},
+		},
+		{
+			&BitVec{Count: 32, Size: 2, Layout: LSBFirst, Data: []uint64{0}},
+			0, 2, []uint64{2},
+		},
+		{
+			&BitVec{Count: 42, Size: 3, Layout: LSBFirst, Data: []uint64{0, 0}},
+			21, 5, []uint64{9223372036854775808, 2},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.bitvec.Set(test.idx, test.val)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, test.output, test.bitvec.Data)
+
+		state, err := test.bitvec.State(test.idx)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, test.val, state)
+
+		indexes, err := test.bitvec.Indexes(test.val)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Contains(t, indexes, test.idx)
+
+		err = test.bitvec.Unset(test.idx)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, make([]uint64, len(test.output)), test.bitvec.Data)
+	}
+}
+
+func TestBitVec_Relayout(t *testing.T) {
+	vec, err := NewBitVec(42, 3)
+	require.Nil(t, err, "Unexpected Error")
+
+	for i := uint64(0); i < vec.Count; i++ {
+		require.Nil(t, vec.Set(i, i%8))
+	}
+
+	require.Nil(t, vec.Relayout(LSBFirst))
+	assert.Equal(t, LSBFirst, vec.Layout)
+
+	for i := uint64(0); i < vec.Count; i++ {
+		state, err := vec.State(i)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, i%8, state)
+	}
+
+	// Slot 0 holds 0 and slot 1 holds 1, so bit 3 is the first bit set
+	assert.Equal(t, uint64(8), vec.Data[0]&15)
+
+	require.Nil(t, vec.Relayout(MSBFirst))
+	assert.Equal(t, MSBFirst, vec.Layout)
+
+	for i := uint64(0); i < vec.Count; i++ {
+		state, err := vec.State(i)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, i%8, state)
+	}
+
+	assert.EqualError(t, vec.Relayout(Layout(9)), "unknown bit layout: 9")
+}
+
+func TestDiBit_Relayout(t *testing.T) {
+	vec := NewDiBit(40)
+	for i := uint64(0); i < vec.Count; i++ {
+		require.Nil(t, vec.Set(i, i%4))
+	}
+
+	require.Nil(t, vec.Relayout(LSBFirst))
+	assert.Equal(t, uint64(0b11100100), vec.Data[0]&255)
+
+	for i := uint64(0); i < vec.Count; i++ {
+		state, err := vec.State(i)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, i%4, state)
+	}
+}
+
+func TestCopyBits(t *testing.T) {
+	rng := rand.New(rand.NewSource(41))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for n := 0; n < 500; n++ {
+			src := make([]uint64, 6)
+			for i := range src {
+				src[i] = rng.Uint64()
+			}
+
+			length := uint64(rng.Intn(200))
+			srcPos, dstPos := uint64(rng.Intn(384-int(length)+1)), uint64(rng.Intn(384-int(length)+1))
+
+			// Copy bit by bit through a buffer as the reference
+			expected := make([]uint64, 6)
+			copy(expected, src)
+			bits := make([]uint64, length)
+			for i := range bits {
+				bits[i] = readBits(src, layout, srcPos+uint64(i), 1)
+			}
+
+			for i, bit := range bits {
+				writeBits(expected, layout, dstPos+uint64(i), 1, bit)
+			}
+
+			// Copy within the same data, overlapping in either direction
+			output := make([]uint64, 6)
+			copy(output, src)
+			copyBits(output, dstPos, output, srcPos, length, layout)
+			assert.Equal(t, expected, output)
+		}
+	}
+}
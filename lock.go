@@ -0,0 +1,26 @@
+package bitvec
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// lockPair acquires the mutexes a and b in the order of their addresses, so that concurrent calls with
+// the mutexes swapped cannot deadlock, and returns a function that releases both. A mutex passed as
+// both a and b is only acquired once.
+func lockPair(a, b *sync.Mutex) (unlock func()) {
+	if uintptr(unsafe.Pointer(b)) < uintptr(unsafe.Pointer(a)) {
+		a, b = b, a
+	}
+
+	a.Lock()
+	if b == a {
+		return a.Unlock
+	}
+
+	b.Lock()
+	return func() {
+		b.Unlock()
+		a.Unlock()
+	}
+}
@@ -0,0 +1,49 @@
+package bitvec
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockPair(t *testing.T) {
+	var a, b sync.Mutex
+
+	// Both mutexes are held until unlock is called
+	unlock := lockPair(&a, &b)
+	assert.False(t, a.TryLock())
+	assert.False(t, b.TryLock())
+
+	unlock()
+	assert.True(t, a.TryLock())
+	assert.True(t, b.TryLock())
+	a.Unlock()
+	b.Unlock()
+
+	// The same mutex is only acquired once
+	unlock = lockPair(&a, &a)
+	assert.False(t, a.TryLock())
+
+	unlock()
+	assert.True(t, a.TryLock())
+	a.Unlock()
+
+	// Concurrent calls with the mutexes swapped do not deadlock
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				if g%2 == 0 {
+					lockPair(&a, &b)()
+				} else {
+					lockPair(&b, &a)()
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+}
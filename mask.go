@@ -0,0 +1,83 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// matchMask returns a BitVec with a Size of 1 and the given Layout whose state is 1 at every index
+// of count slots of size bits in the data with the given state. Aligned slots are compared a word at a time.
+func matchMask(data []uint64, layout Layout, count, size, state uint64) *BitVec {
+	// Error can be ignored because a Size of 1 is always allowed and the Layout is known
+	output, _ := NewBitVecWithLayout(count, 1, layout)
+
+	if !aligned(size) {
+		reader := slotReader{data: data, layout: layout, size: size}
+		writer := slotWriter{data: output.Data, layout: layout, size: 1}
+		for i := uint64(0); i < count; i++ {
+			if reader.read() == state {
+				writer.write(1)
+			} else {
+				writer.write(0)
+			}
+		}
+
+		writer.flush()
+		return output
+	}
+
+	used, perWord := count*size, 64/size
+	for w := range data {
+		mask := wordMatches(data, layout, size, used, w, state)
+
+		// Pack the matching fields of the word into as many bits, in the order of their slots
+		bits := uint64(0)
+		for mask != 0 {
+			var bit int
+			bit, mask = firstField(layout, mask)
+
+			if slot := fieldSlot(layout, size, bit); layout == LSBFirst {
+				bits |= 1 << slot
+			} else {
+				bits |= 1 << (perWord - 1 - slot)
+			}
+		}
+
+		if bits != 0 {
+			writeBits(output.Data, layout, uint64(w)*perWord, perWord, bits)
+		}
+	}
+
+	return output
+}
+
+// MatchMask is a method of BitVec that returns a BitVec with a Size of 1 and the same Count and Layout,
+// whose state is 1 at every index with the given state and 0 elsewhere.
+// Returns an error if state value exceeds the maximum for the BitVec.
+func (vec *BitVec) MatchMask(state uint64) (*BitVec, error) {
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return matchMask(vec.Data, vec.Layout, vec.Count, vec.Size, state), nil
+}
+
+// MatchMask is a method of DiBit that returns a BitVec with a Size of 1 and the same Count and Layout,
+// whose state is 1 at every index with the given state and 0 elsewhere.
+// Returns an error if state value exceeds the maximum for the DiBit.
+func (vec *DiBit) MatchMask(state uint64) (*BitVec, error) {
+	// Check for state value too large for DiBit
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return matchMask(vec.Data, vec.Layout, vec.Count, DIBITSIZE, state), nil
+}
@@ -0,0 +1,74 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_MatchMask(t *testing.T) {
+	rng := rand.New(rand.NewSource(48))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 3, 4, 8, 64} {
+			vec := randomBitVec(rng, 333, size, layout)
+			if size > 2 {
+				// Keep the states small so that some of them match
+				for i := uint64(0); i < vec.Count; i++ {
+					state, _ := vec.State(i)
+					require.Nil(t, vec.Unset(i))
+					require.Nil(t, vec.Set(i, state%3))
+				}
+			}
+
+			states := vec.ToStates()
+			for _, state := range []uint64{0, 1} {
+				mask, err := vec.MatchMask(state)
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, uint64(333), mask.Count)
+				assert.Equal(t, uint64(1), mask.Size)
+				assert.Equal(t, layout, mask.Layout)
+
+				expected := make([]uint64, len(states))
+				for i := range states {
+					if states[i] == state {
+						expected[i] = 1
+					}
+				}
+
+				// The padding bits of the mask are clear
+				assert.Equal(t, packStates(1, layout, expected), mask.Data)
+
+				// The mask feeds into the rank directory
+				matches, _ := vec.Indexes(state)
+				rank, err := mask.Rank(1, mask.Count)
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, uint64(len(matches)), rank)
+			}
+		}
+	}
+
+	vec, _ := NewBitVec(10, 2)
+	_, err := vec.MatchMask(4)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 3)")
+}
+
+func TestDiBit_MatchMask(t *testing.T) {
+	vec := NewDiBit(70)
+	require.Nil(t, vec.Set(0, 2))
+	require.Nil(t, vec.Set(33, 2))
+	require.Nil(t, vec.Set(69, 2))
+
+	mask, err := vec.MatchMask(2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0x8000000040000000, 0x0400000000000000}, mask.Data)
+
+	mask, err = vec.MatchMask(0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0x7FFFFFFFBFFFFFFF, 0xF800000000000000}, mask.Data)
+
+	_, err = vec.MatchMask(4)
+	assert.EqualError(t, err, "state too large for dibit state (max: 3)")
+}
@@ -0,0 +1,328 @@
+//go:build linux
+
+package bitvec
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// MapMode is the access mode of a MappedBitVec.
+type MapMode uint8
+
+const (
+	// MapReadOnly maps an existing file for reading only.
+	// Set and Unset return an error.
+	MapReadOnly MapMode = iota
+
+	// MapReadWrite maps a file for reading and writing,
+	// creating it with all states unset if it does not exist.
+	MapReadWrite
+)
+
+// MappedBitVec is a BitVec whose Data words live in a memory-mapped file rather than on the heap.
+// The file holds the binary serialization format of a BitVec, so it can be read with UnmarshalBinary
+// and a file written from MarshalBinary can be mapped. Its words are only written to the file on
+// Sync, Close or at the discretion of the operating system.
+type MappedBitVec struct {
+	// mu guards the lifetime of the mapping, which Close holds exclusively
+	mu sync.RWMutex
+
+	// vec is the BitVec whose Data aliases the mapping
+	vec *BitVec
+	// file is the mapped file
+	file *os.File
+	// mapping is the mapped memory of the whole file
+	mapping []byte
+	// mode is the access mode of the mapping
+	mode MapMode
+}
+
+// littleEndian reports whether the host stores words little-endian,
+// which the words of a mapped file require.
+func littleEndian() bool {
+	word := uint16(1)
+	return *(*byte)(unsafe.Pointer(&word)) == 1
+}
+
+// OpenMapped opens the file at path as a MappedBitVec with the given Count and Size.
+// In MapReadWrite mode a missing file is created with the MSBFirst layout; the Layout of an
+// existing file is read from its header. Returns an error if Size is greater than MAXVECSIZE,
+// if the file cannot be opened or mapped, or if its header does not match the Count and Size.
+func OpenMapped(path string, count, size uint64, mode MapMode) (*MappedBitVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	// Check that the words for the Count and Size can be addressed
+	if err := checkCount(count, size); err != nil {
+		return nil, err
+	}
+
+	// Check that the words can be used in place
+	if !littleEndian() {
+		return nil, errors.New("memory-mapped bitvec requires a little-endian host")
+	}
+
+	flag, prot := os.O_RDONLY, syscall.PROT_READ
+	switch mode {
+	case MapReadOnly:
+	case MapReadWrite:
+		flag, prot = os.O_RDWR|os.O_CREATE, syscall.PROT_READ|syscall.PROT_WRITE
+	default:
+		return nil, errors.Errorf("unknown map mode: %d", mode)
+	}
+
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open mapped bitvec file")
+	}
+
+	mapped, err := mapFile(file, count, size, mode, prot)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return mapped, nil
+}
+
+// mapFile maps the opened file, initializing its header if it is empty and writable.
+func mapFile(file *os.File, count, size uint64, mode MapMode, prot int) (*MappedBitVec, error) {
+	words := (count*size + 63) / 64
+	length := BINARYHEADERSIZE + 8*words
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stat mapped bitvec file")
+	}
+
+	// Initialize a new file with the header and unset states
+	if info.Size() == 0 && mode == MapReadWrite {
+		if _, err := file.WriteAt(encodeHeader(count, size, MSBFirst), 0); err != nil {
+			return nil, errors.Wrap(err, "failed to write mapped bitvec header")
+		}
+
+		if err := file.Truncate(int64(length)); err != nil {
+			return nil, errors.Wrap(err, "failed to resize mapped bitvec file")
+		}
+	} else if uint64(info.Size()) != length {
+		return nil, errors.Errorf("mapped bitvec file length does not match count and size (want: %v)", length)
+	}
+
+	mapping, err := syscall.Mmap(int(file.Fd()), 0, int(length), prot, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to map bitvec file")
+	}
+
+	// Check that the header matches the requested Count and Size
+	fileCount, fileSize, layout, err := decodeHeader(mapping)
+	if err == nil && (fileCount != count || fileSize != size) {
+		err = errors.Errorf("mapped bitvec header does not match count and size ([%v|%v])", fileCount, fileSize)
+	}
+
+	if err != nil {
+		_ = syscall.Munmap(mapping)
+		return nil, err
+	}
+
+	// Alias the Data of the BitVec to the words after the header
+	data := make([]uint64, 0)
+	if words > 0 {
+		data = unsafe.Slice((*uint64)(unsafe.Pointer(&mapping[BINARYHEADERSIZE])), words)
+	}
+
+	return &MappedBitVec{
+		vec:  &BitVec{Count: count, Size: size, Layout: layout, Data: data},
+		file: file, mapping: mapping, mode: mode,
+	}, nil
+}
+
+// checkOpen returns an error if the MappedBitVec was closed.
+func (mapped *MappedBitVec) checkOpen() error {
+	if mapped.mapping == nil {
+		return errors.New("mapped bitvec is closed")
+	}
+
+	return nil
+}
+
+// checkWritable returns an error if the MappedBitVec was closed or is read-only.
+func (mapped *MappedBitVec) checkWritable() error {
+	if err := mapped.checkOpen(); err != nil {
+		return err
+	}
+
+	if mapped.mode != MapReadWrite {
+		return errors.New("mapped bitvec is read-only")
+	}
+
+	return nil
+}
+
+// String implements the Stringer interface for MappedBitVec
+func (mapped *MappedBitVec) String() string {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	if mapped.mapping == nil {
+		return fmt.Sprintf("[%v|%v] closed", mapped.vec.Count, mapped.vec.Size)
+	}
+
+	return mapped.vec.String()
+}
+
+// Count is a method of MappedBitVec that returns the number of responses.
+func (mapped *MappedBitVec) Count() uint64 {
+	return mapped.vec.Count
+}
+
+// Size is a method of MappedBitVec that returns the number of bits required for a response.
+func (mapped *MappedBitVec) Size() uint64 {
+	return mapped.vec.Size
+}
+
+// Layout is a method of MappedBitVec that returns the order in which responses are packed.
+func (mapped *MappedBitVec) Layout() Layout {
+	return mapped.vec.Layout
+}
+
+// MaxState is a method of MappedBitVec that returns the maximum value for a state for that MappedBitVec.
+func (mapped *MappedBitVec) MaxState() uint64 {
+	return mapped.vec.MaxState()
+}
+
+// Set is a method of MappedBitVec that sets a given state at given index, like BitVec.Set.
+// Returns an error if the MappedBitVec is read-only or closed, if the index is out of bounds
+// or if the state value exceeds the maximum for the MappedBitVec.
+func (mapped *MappedBitVec) Set(index, state uint64) error {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	if err := mapped.checkWritable(); err != nil {
+		return err
+	}
+
+	return mapped.vec.Set(index, state)
+}
+
+// Unset is a method of MappedBitVec that unsets the state for a given index, like BitVec.Unset.
+// Returns an error if the MappedBitVec is read-only or closed or if the index is out of bounds.
+func (mapped *MappedBitVec) Unset(index uint64) error {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	if err := mapped.checkWritable(); err != nil {
+		return err
+	}
+
+	return mapped.vec.Unset(index)
+}
+
+// Has is a method of MappedBitVec that checks whether the state at a given index matches the given state, like BitVec.Has.
+// Returns an error if the MappedBitVec is closed, if the index is out of bounds or if the state value exceeds the maximum.
+func (mapped *MappedBitVec) Has(index, state uint64) (bool, error) {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	if err := mapped.checkOpen(); err != nil {
+		return false, err
+	}
+
+	return mapped.vec.Has(index, state)
+}
+
+// State is a method of MappedBitVec that returns the state at a given index, like BitVec.State.
+// Returns an error if the MappedBitVec is closed or if the index is out of bounds.
+func (mapped *MappedBitVec) State(index uint64) (uint64, error) {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	if err := mapped.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	return mapped.vec.State(index)
+}
+
+// Indexes is a method of MappedBitVec that returns the slice of indexes matching the given state, like BitVec.Indexes.
+// Returns an error if the MappedBitVec is closed or if the state value exceeds the maximum.
+func (mapped *MappedBitVec) Indexes(state uint64) ([]uint64, error) {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	if err := mapped.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	return mapped.vec.Indexes(state)
+}
+
+// Sync is a method of MappedBitVec that writes any modified words back to the file.
+// Returns an error if the MappedBitVec is closed or the write fails.
+func (mapped *MappedBitVec) Sync() error {
+	// Acquire the read lock, so the mapping is not closed concurrently
+	mapped.mu.RLock()
+	defer mapped.mu.RUnlock()
+
+	return mapped.sync()
+}
+
+// sync writes any modified words back to the file while the mapping is locked.
+func (mapped *MappedBitVec) sync() error {
+	if err := mapped.checkOpen(); err != nil {
+		return err
+	}
+
+	if mapped.mode != MapReadWrite {
+		return nil
+	}
+
+	// Acquire the mutex, so no write is in progress
+	mapped.vec.mu.Lock()
+	defer mapped.vec.mu.Unlock()
+
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC,
+		uintptr(unsafe.Pointer(&mapped.mapping[0])), uintptr(len(mapped.mapping)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errors.Wrap(errno, "failed to sync mapped bitvec")
+	}
+
+	return nil
+}
+
+// Close is a method of MappedBitVec that syncs any modified words, unmaps and closes the file.
+// The MappedBitVec cannot be used afterwards. Returns an error if the MappedBitVec is already closed.
+func (mapped *MappedBitVec) Close() error {
+	// Acquire the write lock, so no operation is in progress
+	mapped.mu.Lock()
+	defer mapped.mu.Unlock()
+
+	if err := mapped.sync(); err != nil {
+		return err
+	}
+
+	mapped.vec.Data = nil
+	if err := syscall.Munmap(mapped.mapping); err != nil {
+		return errors.Wrap(err, "failed to unmap bitvec file")
+	}
+
+	mapped.mapping = nil
+	if err := mapped.file.Close(); err != nil {
+		return errors.Wrap(err, "failed to close mapped bitvec file")
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+//go:build linux
+
+package bitvec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenMapped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vec.bin")
+
+	mapped, err := OpenMapped(path, 42, 3, MapReadWrite)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(42), mapped.Count())
+	assert.Equal(t, uint64(3), mapped.Size())
+	assert.Equal(t, MSBFirst, mapped.Layout())
+
+	require.Nil(t, mapped.Set(21, 5))
+	require.Nil(t, mapped.Set(41, 7))
+	require.Nil(t, mapped.Unset(41))
+	require.Nil(t, mapped.Sync())
+
+	// The file holds the binary serialization format
+	data, err := os.ReadFile(path)
+	require.Nil(t, err, "Unexpected Error")
+
+	vec := new(BitVec)
+	require.Nil(t, vec.UnmarshalBinary(data))
+	assert.Equal(t, []uint64{1, 4611686018427387904}, vec.Data)
+
+	require.Nil(t, mapped.Close())
+	assert.EqualError(t, mapped.Close(), "mapped bitvec is closed")
+	assert.EqualError(t, mapped.Set(1, 1), "mapped bitvec is closed")
+	_, err = mapped.State(1)
+	assert.EqualError(t, err, "mapped bitvec is closed")
+	assert.Equal(t, "[42|3] closed", mapped.String())
+
+	// Reopen the file for reading only
+	mapped, err = OpenMapped(path, 42, 3, MapReadOnly)
+	require.Nil(t, err, "Unexpected Error")
+
+	state, err := mapped.State(21)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(5), state)
+
+	indexes, err := mapped.Indexes(5)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{21}, indexes)
+
+	exists, err := mapped.Has(41, 0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+
+	assert.EqualError(t, mapped.Set(1, 1), "mapped bitvec is read-only")
+	assert.EqualError(t, mapped.Unset(1), "mapped bitvec is read-only")
+	require.Nil(t, mapped.Close())
+}
+
+func TestOpenMapped_MarshalBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vec.bin")
+
+	vec, err := NewBitVecWithLayout(100, 5, LSBFirst)
+	require.Nil(t, err, "Unexpected Error")
+	require.Nil(t, vec.Set(99, 31))
+
+	data, err := vec.MarshalBinary()
+	require.Nil(t, err, "Unexpected Error")
+	require.Nil(t, os.WriteFile(path, data, 0o644))
+
+	mapped, err := OpenMapped(path, 100, 5, MapReadWrite)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, LSBFirst, mapped.Layout())
+
+	state, err := mapped.State(99)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(31), state)
+	require.Nil(t, mapped.Close())
+}
+
+func TestOpenMapped_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := OpenMapped(filepath.Join(dir, "missing.bin"), 10, 2, MapReadOnly)
+	assert.Error(t, err)
+
+	_, err = OpenMapped(filepath.Join(dir, "vec.bin"), 10, 70, MapReadWrite)
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+
+	_, err = OpenMapped(filepath.Join(dir, "vec.bin"), 1<<63, 2, MapReadWrite)
+	assert.EqualError(t, err, "count too large for bitvec size (max: 9223372036854775776)")
+
+	_, err = OpenMapped(filepath.Join(dir, "vec.bin"), 10, 2, MapMode(5))
+	assert.EqualError(t, err, "unknown map mode: 5")
+
+	mapped, err := OpenMapped(filepath.Join(dir, "vec.bin"), 100, 2, MapReadWrite)
+	require.Nil(t, err, "Unexpected Error")
+	require.Nil(t, mapped.Close())
+
+	_, err = OpenMapped(filepath.Join(dir, "vec.bin"), 200, 2, MapReadOnly)
+	assert.EqualError(t, err, "mapped bitvec file length does not match count and size (want: 80)")
+
+	_, err = OpenMapped(filepath.Join(dir, "vec.bin"), 50, 4, MapReadOnly)
+	assert.EqualError(t, err, "mapped bitvec header does not match count and size ([100|2])")
+}
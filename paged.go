@@ -0,0 +1,424 @@
+package bitvec
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// cachedPage is a page of words of a pageCache.
+type cachedPage struct {
+	// index is the position of the page in the file
+	index uint64
+	// words are the words of the page, fewer for the last page of the file
+	words []uint64
+	// dirty is whether the words were modified since they were read or written back
+	dirty bool
+}
+
+// pageCache is a WordStore that keeps the words of a file in fixed size pages,
+// caching the most recently used pages in memory up to a number of pages.
+// Dirty pages are written back to the file when they are evicted or flushed.
+type pageCache struct {
+	// file holds the words after the header of the binary serialization format
+	file *os.File
+	// pageWords is the number of words of a page
+	pageWords uint64
+	// words is the number of words in the file
+	words uint64
+	// capacity is the maximum number of cached pages
+	capacity int
+
+	// lru orders the cached pages from most to least recently used
+	lru *list.List
+	// pages maps the index of a cached page to its element in lru
+	pages map[uint64]*list.Element
+}
+
+// newPageCache is a constructor function for a pageCache.
+func newPageCache(file *os.File, words, pageWords uint64, capacity int) *pageCache {
+	return &pageCache{
+		file: file, pageWords: pageWords, words: words, capacity: capacity,
+		lru: list.New(), pages: make(map[uint64]*list.Element),
+	}
+}
+
+// Load implements the WordStore interface for pageCache
+func (cache *pageCache) Load(i uint64) (uint64, error) {
+	page, err := cache.page(i / cache.pageWords)
+	if err != nil {
+		return 0, err
+	}
+
+	return page.words[i%cache.pageWords], nil
+}
+
+// Store implements the WordStore interface for pageCache
+func (cache *pageCache) Store(i, word uint64) error {
+	page, err := cache.page(i / cache.pageWords)
+	if err != nil {
+		return err
+	}
+
+	page.words[i%cache.pageWords] = word
+	page.dirty = true
+	return nil
+}
+
+// Len implements the WordStore interface for pageCache
+func (cache *pageCache) Len() uint64 {
+	return cache.words
+}
+
+// page returns the page at the given index, reading it from the file if it is not cached.
+// The least recently used page is evicted if the cache is full.
+func (cache *pageCache) page(index uint64) (*cachedPage, error) {
+	if element, ok := cache.pages[index]; ok {
+		cache.lru.MoveToFront(element)
+		return element.Value.(*cachedPage), nil
+	}
+
+	// Evict the least recently used page
+	if cache.lru.Len() >= cache.capacity {
+		element := cache.lru.Back()
+		if err := cache.writeBack(element.Value.(*cachedPage)); err != nil {
+			return nil, err
+		}
+
+		cache.lru.Remove(element)
+		delete(cache.pages, element.Value.(*cachedPage).index)
+	}
+
+	// The last page holds the remaining words
+	start := index * cache.pageWords
+	n := cache.pageWords
+	if cache.words-start < n {
+		n = cache.words - start
+	}
+
+	buf := make([]byte, 8*n)
+	if _, err := cache.file.ReadAt(buf, BINARYHEADERSIZE+int64(8*start)); err != nil {
+		return nil, errors.Wrapf(err, "failed to read page %v", index)
+	}
+
+	page := &cachedPage{index: index, words: make([]uint64, n)}
+	for i := range page.words {
+		page.words[i] = binary.LittleEndian.Uint64(buf[8*i:])
+	}
+
+	cache.pages[index] = cache.lru.PushFront(page)
+	return page, nil
+}
+
+// writeBack writes the words of a dirty page to the file.
+func (cache *pageCache) writeBack(page *cachedPage) error {
+	if !page.dirty {
+		return nil
+	}
+
+	buf := make([]byte, 8*len(page.words))
+	for i, word := range page.words {
+		binary.LittleEndian.PutUint64(buf[8*i:], word)
+	}
+
+	if _, err := cache.file.WriteAt(buf, BINARYHEADERSIZE+int64(8*page.index*cache.pageWords)); err != nil {
+		return errors.Wrapf(err, "failed to write page %v", page.index)
+	}
+
+	page.dirty = false
+	return nil
+}
+
+// flush writes all dirty pages to the file.
+func (cache *pageCache) flush() error {
+	for element := cache.lru.Front(); element != nil; element = element.Next() {
+		if err := cache.writeBack(element.Value.(*cachedPage)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PagedBitVec is a BitVec whose Data words live in a file and are accessed in fixed size pages,
+// for vectors larger than the available memory. The most recently used pages are cached in memory
+// up to a byte budget, and modified pages are written back when they are evicted or on Flush.
+// The file holds the binary serialization format of a BitVec, like the file of a MappedBitVec.
+type PagedBitVec struct {
+	// mu guards the lifetime of the file, which Close holds exclusively
+	mu sync.RWMutex
+
+	// vec is the StoreBitVec operating on the cached pages
+	vec *StoreBitVec
+	// cache holds the cached pages of the file
+	cache *pageCache
+	// file is the paged file, nil once closed
+	file *os.File
+}
+
+// OpenPaged opens the file at path as a PagedBitVec with the given Count and Size, creating it with
+// all states unset and the MSBFirst layout if it does not exist. The Layout of an existing file is
+// read from its header. Pages hold pageSize bytes, which must be a positive multiple of 8, and at most
+// budget bytes of pages are cached. Returns an error if Size is greater than MAXVECSIZE, if the budget
+// does not fit a page, if the file cannot be opened or if its header does not match the Count and Size.
+func OpenPaged(path string, count, size, pageSize, budget uint64) (*PagedBitVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	// Check that the words for the Count and Size can be addressed
+	if err := checkCount(count, size); err != nil {
+		return nil, err
+	}
+
+	// Check that pages hold whole words and fit the budget
+	if pageSize == 0 || pageSize%8 != 0 {
+		return nil, errors.Errorf("page size must be a positive multiple of 8 (size: %v)", pageSize)
+	}
+
+	if budget < pageSize {
+		return nil, errors.Errorf("page cache budget too small for page size (min: %v)", pageSize)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open paged bitvec file")
+	}
+
+	layout, err := initPagedFile(file, count, size)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	words := (count*size + 63) / 64
+	cache := newPageCache(file, words, pageSize/8, int(budget/pageSize))
+	vec, err := NewStoreBitVecWithLayout(count, size, layout, cache)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &PagedBitVec{vec: vec, cache: cache, file: file}, nil
+}
+
+// initPagedFile initializes the header of an empty file and checks the header of an existing one.
+// Returns the Layout of the file.
+func initPagedFile(file *os.File, count, size uint64) (Layout, error) {
+	length := BINARYHEADERSIZE + 8*((count*size+63)/64)
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to stat paged bitvec file")
+	}
+
+	// Initialize a new file with the header and unset states
+	if info.Size() == 0 {
+		if _, err := file.WriteAt(encodeHeader(count, size, MSBFirst), 0); err != nil {
+			return 0, errors.Wrap(err, "failed to write paged bitvec header")
+		}
+
+		if err := file.Truncate(int64(length)); err != nil {
+			return 0, errors.Wrap(err, "failed to resize paged bitvec file")
+		}
+
+		return MSBFirst, nil
+	}
+
+	if uint64(info.Size()) != length {
+		return 0, errors.Errorf("paged bitvec file length does not match count and size (want: %v)", length)
+	}
+
+	// Check that the header matches the requested Count and Size
+	header := make([]byte, BINARYHEADERSIZE)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return 0, errors.Wrap(err, "failed to read paged bitvec header")
+	}
+
+	fileCount, fileSize, layout, err := decodeHeader(header)
+	if err != nil {
+		return 0, err
+	}
+
+	if fileCount != count || fileSize != size {
+		return 0, errors.Errorf("paged bitvec header does not match count and size ([%v|%v])", fileCount, fileSize)
+	}
+
+	return layout, nil
+}
+
+// checkOpen returns an error if the PagedBitVec was closed.
+func (paged *PagedBitVec) checkOpen() error {
+	if paged.file == nil {
+		return errors.New("paged bitvec is closed")
+	}
+
+	return nil
+}
+
+// String implements the Stringer interface for PagedBitVec
+func (paged *PagedBitVec) String() string {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	if paged.file == nil {
+		return fmt.Sprintf("[%v|%v] closed", paged.vec.Count, paged.vec.Size)
+	}
+
+	return fmt.Sprintf("[%v|%v] paged(%v)", paged.vec.Count, paged.vec.Size, paged.file.Name())
+}
+
+// Count is a method of PagedBitVec that returns the number of responses.
+func (paged *PagedBitVec) Count() uint64 {
+	return paged.vec.Count
+}
+
+// Size is a method of PagedBitVec that returns the number of bits required for a response.
+func (paged *PagedBitVec) Size() uint64 {
+	return paged.vec.Size
+}
+
+// Layout is a method of PagedBitVec that returns the order in which responses are packed.
+func (paged *PagedBitVec) Layout() Layout {
+	return paged.vec.Layout
+}
+
+// MaxState is a method of PagedBitVec that returns the maximum value for a state for that PagedBitVec.
+func (paged *PagedBitVec) MaxState() uint64 {
+	return paged.vec.MaxState()
+}
+
+// Set is a method of PagedBitVec that sets a given state at given index, like BitVec.Set.
+// Returns an error if the PagedBitVec is closed, if the index is out of bounds,
+// if the state value exceeds the maximum or if a page cannot be read or written back.
+func (paged *PagedBitVec) Set(index, state uint64) error {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	if err := paged.checkOpen(); err != nil {
+		return err
+	}
+
+	return paged.vec.Set(index, state)
+}
+
+// Unset is a method of PagedBitVec that unsets the state for a given index, like BitVec.Unset.
+// Returns an error if the PagedBitVec is closed, if the index is out of bounds
+// or if a page cannot be read or written back.
+func (paged *PagedBitVec) Unset(index uint64) error {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	if err := paged.checkOpen(); err != nil {
+		return err
+	}
+
+	return paged.vec.Unset(index)
+}
+
+// Has is a method of PagedBitVec that checks whether the state at a given index matches the given state, like BitVec.Has.
+// Returns an error if the PagedBitVec is closed, if the index is out of bounds, if the state value exceeds the maximum
+// or if a page cannot be read or written back.
+func (paged *PagedBitVec) Has(index, state uint64) (bool, error) {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	if err := paged.checkOpen(); err != nil {
+		return false, err
+	}
+
+	return paged.vec.Has(index, state)
+}
+
+// State is a method of PagedBitVec that returns the state at a given index, like BitVec.State.
+// Returns an error if the PagedBitVec is closed, if the index is out of bounds
+// or if a page cannot be read or written back.
+func (paged *PagedBitVec) State(index uint64) (uint64, error) {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	if err := paged.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	return paged.vec.State(index)
+}
+
+// Indexes is a method of PagedBitVec that returns the slice of indexes matching the given state, like BitVec.Indexes.
+// Returns an error if the PagedBitVec is closed, if the state value exceeds the maximum
+// or if a page cannot be read or written back.
+func (paged *PagedBitVec) Indexes(state uint64) ([]uint64, error) {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	if err := paged.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	return paged.vec.Indexes(state)
+}
+
+// Flush is a method of PagedBitVec that writes all modified pages back to the file and syncs it.
+// Returns an error if the PagedBitVec is closed or the write fails.
+func (paged *PagedBitVec) Flush() error {
+	// Acquire the read lock, so the file is not closed concurrently
+	paged.mu.RLock()
+	defer paged.mu.RUnlock()
+
+	return paged.flush()
+}
+
+// flush writes all modified pages back to the file while the file is locked.
+func (paged *PagedBitVec) flush() error {
+	if err := paged.checkOpen(); err != nil {
+		return err
+	}
+
+	// Acquire the mutex, so no operation is using the cache
+	paged.vec.mu.Lock()
+	defer paged.vec.mu.Unlock()
+
+	if err := paged.cache.flush(); err != nil {
+		return err
+	}
+
+	if err := paged.file.Sync(); err != nil {
+		return errors.Wrap(err, "failed to sync paged bitvec file")
+	}
+
+	return nil
+}
+
+// Close is a method of PagedBitVec that flushes any modified pages and closes the file.
+// The PagedBitVec cannot be used afterwards. Returns an error if the PagedBitVec is already closed.
+func (paged *PagedBitVec) Close() error {
+	// Acquire the write lock, so no operation is in progress
+	paged.mu.Lock()
+	defer paged.mu.Unlock()
+
+	if err := paged.flush(); err != nil {
+		return err
+	}
+
+	file := paged.file
+	paged.file = nil
+	paged.cache.lru.Init()
+	paged.cache.pages = make(map[uint64]*list.Element)
+
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "failed to close paged bitvec file")
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package bitvec
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenPaged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vec.bin")
+	rng := rand.New(rand.NewSource(36))
+
+	// Pages of two words in a cache of three pages, so slots of 13 bits straddle pages
+	paged, err := OpenPaged(path, 1000, 13, 16, 48)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(1000), paged.Count())
+	assert.Equal(t, uint64(13), paged.Size())
+	assert.Equal(t, MSBFirst, paged.Layout())
+	assert.Equal(t, uint64(8191), paged.MaxState())
+
+	vec, err := NewBitVec(1000, 13)
+	require.Nil(t, err, "Unexpected Error")
+
+	// Slot 9 spans bits 117 to 129, from the first page into the second
+	require.Nil(t, paged.Set(9, 8191))
+	require.Nil(t, vec.Set(9, 8191))
+
+	for n := 0; n < 2000; n++ {
+		index, state := uint64(rng.Intn(1000)), uint64(rng.Intn(8192))
+		if rng.Intn(4) == 0 {
+			require.Nil(t, paged.Unset(index))
+			require.Nil(t, vec.Unset(index))
+		} else {
+			require.Nil(t, paged.Set(index, state))
+			require.Nil(t, vec.Set(index, state))
+		}
+
+		assert.LessOrEqual(t, paged.cache.lru.Len(), 3)
+	}
+
+	for i := uint64(0); i < 1000; i++ {
+		expected, _ := vec.State(i)
+		state, err := paged.State(i)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, expected, state)
+	}
+
+	expected, _ := vec.Indexes(0)
+	indexes, err := paged.Indexes(0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, expected, indexes)
+
+	// Flush writes the dirty pages back to the file
+	require.Nil(t, paged.Flush())
+	for element := paged.cache.lru.Front(); element != nil; element = element.Next() {
+		assert.False(t, element.Value.(*cachedPage).dirty)
+	}
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err, "Unexpected Error")
+
+	output := new(BitVec)
+	require.Nil(t, output.UnmarshalBinary(data))
+	assert.Equal(t, vec.Data, output.Data)
+
+	require.Nil(t, paged.Set(999, 77))
+	require.Nil(t, vec.Set(999, 77))
+	require.Nil(t, paged.Close())
+
+	assert.EqualError(t, paged.Close(), "paged bitvec is closed")
+	assert.EqualError(t, paged.Set(1, 1), "paged bitvec is closed")
+	_, err = paged.State(1)
+	assert.EqualError(t, err, "paged bitvec is closed")
+	assert.Equal(t, "[1000|13] closed", paged.String())
+
+	// Reopening the file reads the states written back on Close
+	paged, err = OpenPaged(path, 1000, 13, 4096, 1<<20)
+	require.Nil(t, err, "Unexpected Error")
+
+	exists, err := paged.Has(999, 77)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+
+	indexes, err = paged.Indexes(77)
+	require.Nil(t, err, "Unexpected Error")
+	expected, _ = vec.Indexes(77)
+	assert.Equal(t, expected, indexes)
+	require.Nil(t, paged.Close())
+}
+
+func TestOpenPaged_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vec.bin")
+
+	tests := []struct {
+		count, size, pageSize, budget uint64
+		err                           string
+	}{
+		{10, 70, 8, 8, "state size greater 64 not allowed"},
+		{1 << 63, 2, 8, 8, "count too large for bitvec size (max: 9223372036854775776)"},
+		{10, 2, 0, 8, "page size must be a positive multiple of 8 (size: 0)"},
+		{10, 2, 12, 24, "page size must be a positive multiple of 8 (size: 12)"},
+		{10, 2, 64, 32, "page cache budget too small for page size (min: 64)"},
+	}
+
+	for _, test := range tests {
+		_, err := OpenPaged(path, test.count, test.size, test.pageSize, test.budget)
+		assert.EqualError(t, err, test.err)
+	}
+
+	paged, err := OpenPaged(path, 100, 2, 8, 8)
+	require.Nil(t, err, "Unexpected Error")
+	require.Nil(t, paged.Close())
+
+	_, err = OpenPaged(path, 100, 4, 8, 8)
+	assert.EqualError(t, err, "paged bitvec file length does not match count and size (want: 80)")
+
+	_, err = OpenPaged(path, 50, 4, 8, 8)
+	assert.EqualError(t, err, "paged bitvec header does not match count and size ([100|2])")
+}
@@ -0,0 +1,179 @@
+package bitvec
+
+import (
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// swapSlots exchanges the slots of size bits at the indexes i and j of the data.
+func swapSlots(data []uint64, layout Layout, size, i, j uint64) {
+	a, b := readBits(data, layout, i*size, size), readBits(data, layout, j*size, size)
+	writeBits(data, layout, i*size, size, b)
+	writeBits(data, layout, j*size, size, a)
+}
+
+// reverseSlots reverses the order of count slots of size bits in the data.
+func reverseSlots(data []uint64, layout Layout, count, size uint64) {
+	for i := uint64(0); i < count/2; i++ {
+		swapSlots(data, layout, size, i, count-1-i)
+	}
+}
+
+// validatePermutation returns an error if perm is not a bijection over [0, count).
+func validatePermutation(perm []uint64, count uint64, name string) error {
+	if uint64(len(perm)) != count {
+		return errors.Errorf("permutation length does not match %v count (want: %v)", name, count)
+	}
+
+	seen := make([]bool, count)
+	for _, index := range perm {
+		if index >= count {
+			return errors.Errorf("permutation index too large for %v count (max: %v)", name, count)
+		}
+
+		if seen[index] {
+			return errors.Errorf("permutation is not a bijection (repeated: %v)", index)
+		}
+
+		seen[index] = true
+	}
+
+	return nil
+}
+
+// permuteSlots returns new data with the slot at index i of count slots of size bits moved to index perm[i].
+// The permutation must have been validated.
+func permuteSlots(data []uint64, layout Layout, count, size uint64, perm []uint64) []uint64 {
+	output := make([]uint64, len(data))
+
+	reader := slotReader{data: data, layout: layout, size: size}
+	for i := uint64(0); i < count; i++ {
+		writeBits(output, layout, perm[i]*size, size, reader.read())
+	}
+
+	return output
+}
+
+// shuffleSlots shuffles count slots of size bits in the data with the Fisher-Yates algorithm,
+// drawing random numbers from the source.
+func shuffleSlots(data []uint64, layout Layout, count, size uint64, src rand.Source) {
+	rng := rand.New(src)
+	for i := count; i > 1; i-- {
+		swapSlots(data, layout, size, i-1, uint64(rng.Int63n(int64(i))))
+	}
+}
+
+// Swap is a method of BitVec that exchanges the states at the indexes i and j.
+// Returns an error if either index is out of bounds.
+func (vec *BitVec) Swap(i, j uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds indexes
+	if i >= vec.Count || j >= vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	swapSlots(vec.Data, vec.Layout, vec.Size, i, j)
+	vec.modified()
+
+	return nil
+}
+
+// Reverse is a method of BitVec that reverses the order of the responses.
+func (vec *BitVec) Reverse() {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	reverseSlots(vec.Data, vec.Layout, vec.Count, vec.Size)
+	vec.modified()
+}
+
+// Permute is a method of BitVec that moves the response at every index i to the index perm[i].
+// Returns an error if perm is not a bijection over [0, Count), in which case the BitVec is not modified.
+func (vec *BitVec) Permute(perm []uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check that the permutation maps every index to a distinct index
+	if err := validatePermutation(perm, vec.Count, "bitvec"); err != nil {
+		return err
+	}
+
+	copy(vec.Data, permuteSlots(vec.Data, vec.Layout, vec.Count, vec.Size, perm))
+	vec.modified()
+
+	return nil
+}
+
+// Shuffle is a method of BitVec that randomly reorders the responses, drawing random numbers from
+// the given source. The same seeded source always produces the same order.
+func (vec *BitVec) Shuffle(src rand.Source) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	shuffleSlots(vec.Data, vec.Layout, vec.Count, vec.Size, src)
+	vec.modified()
+}
+
+// Swap is a method of DiBit that exchanges the states at the indexes i and j.
+// Returns an error if either index is out of bounds.
+func (vec *DiBit) Swap(i, j uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds indexes
+	if i >= vec.Count || j >= vec.Count {
+		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	swapSlots(vec.Data, vec.Layout, DIBITSIZE, i, j)
+	vec.modified()
+
+	return nil
+}
+
+// Reverse is a method of DiBit that reverses the order of the responses.
+func (vec *DiBit) Reverse() {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	reverseSlots(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+	vec.modified()
+}
+
+// Permute is a method of DiBit that moves the response at every index i to the index perm[i].
+// Returns an error if perm is not a bijection over [0, Count), in which case the DiBit is not modified.
+func (vec *DiBit) Permute(perm []uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check that the permutation maps every index to a distinct index
+	if err := validatePermutation(perm, vec.Count, "dibit"); err != nil {
+		return err
+	}
+
+	copy(vec.Data, permuteSlots(vec.Data, vec.Layout, vec.Count, DIBITSIZE, perm))
+	vec.modified()
+
+	return nil
+}
+
+// Shuffle is a method of DiBit that randomly reorders the responses, drawing random numbers from
+// the given source. The same seeded source always produces the same order.
+func (vec *DiBit) Shuffle(src rand.Source) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	shuffleSlots(vec.Data, vec.Layout, vec.Count, DIBITSIZE, src)
+	vec.modified()
+}
@@ -0,0 +1,103 @@
+package bitvec
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_Permute(t *testing.T) {
+	rng := rand.New(rand.NewSource(44))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 3, 7, 64} {
+			vec := randomBitVec(rng, 101, size, layout)
+			states := vec.ToStates()
+
+			// Swap
+			require.Nil(t, vec.Swap(3, 97))
+			states[3], states[97] = states[97], states[3]
+			assert.Equal(t, packStates(size, layout, states), vec.Data)
+
+			// Reverse
+			vec.Reverse()
+			for i, j := 0, len(states)-1; i < j; i, j = i+1, j-1 {
+				states[i], states[j] = states[j], states[i]
+			}
+
+			assert.Equal(t, packStates(size, layout, states), vec.Data)
+
+			// Permute
+			perm := make([]uint64, 101)
+			for i, p := range rng.Perm(101) {
+				perm[i] = uint64(p)
+			}
+
+			require.Nil(t, vec.Permute(perm))
+			permuted := make([]uint64, 101)
+			for i, p := range perm {
+				permuted[p] = states[i]
+			}
+
+			assert.Equal(t, packStates(size, layout, permuted), vec.Data)
+
+			// Shuffle keeps the same states and is deterministic for a seed
+			other, _ := NewBitVecFromStates(size, permuted)
+			require.Nil(t, other.Relayout(layout))
+
+			vec.Shuffle(rand.NewSource(7))
+			other.Shuffle(rand.NewSource(7))
+			assert.Equal(t, other.Data, vec.Data)
+
+			shuffled := vec.ToStates()
+			sort.Slice(shuffled, func(i, j int) bool { return shuffled[i] < shuffled[j] })
+			sort.Slice(permuted, func(i, j int) bool { return permuted[i] < permuted[j] })
+			assert.Equal(t, permuted, shuffled)
+		}
+	}
+}
+
+func TestBitVec_Permute_Errors(t *testing.T) {
+	vec, _ := NewBitVec(4, 2)
+	require.Nil(t, vec.Set(0, 3))
+
+	assert.EqualError(t, vec.Swap(1, 4), "index too large for bitvec count (max: 4)")
+	assert.EqualError(t, vec.Permute([]uint64{0, 1, 2}), "permutation length does not match bitvec count (want: 4)")
+	assert.EqualError(t, vec.Permute([]uint64{0, 1, 2, 4}), "permutation index too large for bitvec count (max: 4)")
+	assert.EqualError(t, vec.Permute([]uint64{1, 0, 1, 3}), "permutation is not a bijection (repeated: 1)")
+	assert.Equal(t, []uint64{0xC000000000000000}, vec.Data)
+}
+
+func TestDiBit_Permute(t *testing.T) {
+	vec := NewDiBit(5)
+	for i, state := range []uint64{0, 1, 2, 3, 1} {
+		require.Nil(t, vec.Set(uint64(i), state))
+	}
+
+	states := func() []uint64 {
+		output := make([]uint64, vec.Count)
+		require.Nil(t, vec.StatesAt(rangeIndexes(vec.Count), output))
+		return output
+	}
+
+	require.Nil(t, vec.Swap(0, 3))
+	assert.Equal(t, []uint64{3, 1, 2, 0, 1}, states())
+
+	vec.Reverse()
+	assert.Equal(t, []uint64{1, 0, 2, 1, 3}, states())
+
+	require.Nil(t, vec.Permute([]uint64{4, 3, 2, 1, 0}))
+	assert.Equal(t, []uint64{3, 1, 2, 0, 1}, states())
+
+	vec.Shuffle(rand.NewSource(1))
+	shuffled := states()
+	sort.Slice(shuffled, func(i, j int) bool { return shuffled[i] < shuffled[j] })
+	assert.Equal(t, []uint64{0, 1, 1, 2, 3}, shuffled)
+
+	assert.EqualError(t, vec.Swap(5, 0), "index too large for dibit count (max: 5)")
+	assert.EqualError(t, vec.Permute([]uint64{0, 0, 1, 2, 3}), "permutation is not a bijection (repeated: 0)")
+	assert.EqualError(t, vec.Permute(nil), "permutation length does not match dibit count (want: 5)")
+}
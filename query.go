@@ -0,0 +1,278 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// matchingIndexes returns the indexes of count slots of size bits in the data whose state satisfies match.
+// If fields is not nil and the slots are aligned, whole words are compared at once with fields instead,
+// which must return the word with the most significant bit of every matching field set.
+func matchingIndexes(data []uint64, layout Layout, count, size uint64, match func(uint64) bool, fields func(uint64) uint64) []uint64 {
+	return appendMatching(make([]uint64, 0), data, layout, count, size, match, fields)
+}
+
+// appendMatching appends the indexes found like matchingIndexes to indexes and returns the extended slice.
+func appendMatching(indexes []uint64, data []uint64, layout Layout, count, size uint64, match func(uint64) bool, fields func(uint64) uint64) []uint64 {
+	if fields == nil || !aligned(size) {
+		reader := slotReader{data: data, layout: layout, size: size}
+		for i := uint64(0); i < count; i++ {
+			if match(reader.read()) {
+				indexes = append(indexes, i)
+			}
+		}
+
+		return indexes
+	}
+
+	used, perWord := count*size, 64/size
+	for w := range data {
+		mask := fields(data[w]) & usedBits(layout, used, w)
+
+		for mask != 0 {
+			var bit int
+			bit, mask = firstField(layout, mask)
+			indexes = append(indexes, uint64(w)*perWord+fieldSlot(layout, size, bit))
+		}
+	}
+
+	return indexes
+}
+
+// rangeMatchers returns the state and word matchers for states in [lo, hi] of size bits.
+// The range must not be empty.
+func rangeMatchers(size, lo, hi uint64) (func(uint64) bool, func(uint64) uint64) {
+	match := func(state uint64) bool { return state >= lo && state <= hi }
+	if !aligned(size) {
+		return match, nil
+	}
+
+	low, high := broadcast(size, lo), broadcast(size, hi)
+	return match, func(word uint64) uint64 {
+		return atLeastFields(word, low, size) & atLeastFields(high, word, size)
+	}
+}
+
+// maskedMatchers returns the state and word matchers for states that equal value when masked with mask.
+func maskedMatchers(size, mask, value uint64) (func(uint64) bool, func(uint64) uint64) {
+	match := func(state uint64) bool { return state&mask == value }
+	if !aligned(size) {
+		return match, nil
+	}
+
+	return match, func(word uint64) uint64 { return maskedFields(word, size, mask, value) }
+}
+
+// IndexesWhere is a method of BitVec that returns the slice of indexes whose state satisfies the predicate.
+func (vec *BitVec) IndexesWhere(predicate func(state uint64) bool) []uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, vec.Size, predicate, nil)
+}
+
+// IndexesInRange is a method of BitVec that returns the slice of indexes whose state is in the inclusive range [lo, hi].
+// A hi beyond the maximum for the BitVec is clamped to it, and the result is empty if lo is greater than hi.
+func (vec *BitVec) IndexesInRange(lo, hi uint64) []uint64 {
+	// Clamp the states to those that can occur
+	if hi > vec.MaxState() {
+		hi = vec.MaxState()
+	}
+
+	if lo > hi {
+		return make([]uint64, 0)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	match, fields := rangeMatchers(vec.Size, lo, hi)
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, vec.Size, match, fields)
+}
+
+// IndexesMasked is a method of BitVec that returns the slice of indexes whose state masked with mask equals value.
+// Returns an error if the mask or value exceed the maximum for the BitVec.
+func (vec *BitVec) IndexesMasked(mask, value uint64) ([]uint64, error) {
+	// Check for mask or value too large for BitVec
+	if mask > vec.MaxState() || value > vec.MaxState() {
+		return nil, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	match, fields := maskedMatchers(vec.Size, mask, value)
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, vec.Size, match, fields), nil
+}
+
+// IndexesWhere is a method of DiBit that returns the slice of indexes whose state satisfies the predicate.
+func (vec *DiBit) IndexesWhere(predicate func(state uint64) bool) []uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, DIBITSIZE, predicate, nil)
+}
+
+// IndexesInRange is a method of DiBit that returns the slice of indexes whose state is in the inclusive range [lo, hi].
+// A hi beyond the maximum for the DiBit is clamped to it, and the result is empty if lo is greater than hi.
+func (vec *DiBit) IndexesInRange(lo, hi uint64) []uint64 {
+	// Clamp the states to those that can occur
+	if hi > vec.MaxState() {
+		hi = vec.MaxState()
+	}
+
+	if lo > hi {
+		return make([]uint64, 0)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	match, fields := rangeMatchers(DIBITSIZE, lo, hi)
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, DIBITSIZE, match, fields)
+}
+
+// IndexesMasked is a method of DiBit that returns the slice of indexes whose state masked with mask equals value.
+// Returns an error if the mask or value exceed the maximum for the DiBit.
+func (vec *DiBit) IndexesMasked(mask, value uint64) ([]uint64, error) {
+	// Check for mask or value too large for DiBit
+	if mask > vec.MaxState() || value > vec.MaxState() {
+		return nil, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	match, fields := maskedMatchers(DIBITSIZE, mask, value)
+	return matchingIndexes(vec.Data, vec.Layout, vec.Count, DIBITSIZE, match, fields), nil
+}
+
+// equalMatchers returns the state and word matchers for states equal to state.
+func equalMatchers(size, state uint64) (func(uint64) bool, func(uint64) uint64) {
+	return func(value uint64) bool { return value == state },
+		func(word uint64) uint64 { return matchFields(word, size, state) }
+}
+
+// indexesPage returns at most limit indexes at or after cursor of count slots of size bits in the data
+// with the given state, and the cursor of the next page, which is the next matching index or count.
+func indexesPage(data []uint64, layout Layout, count, size, state, cursor, limit uint64) ([]uint64, uint64) {
+	// The page cannot hold more indexes than are left after the cursor
+	capacity := limit
+	if count-cursor < capacity {
+		capacity = count - cursor
+	}
+
+	indexes := make([]uint64, 0, capacity)
+
+	next, ok := nextIndex(data, layout, count, size, cursor, state)
+	for ok && uint64(len(indexes)) < limit {
+		indexes = append(indexes, next)
+		next, ok = nextIndex(data, layout, count, size, next+1, state)
+	}
+
+	if !ok {
+		next = count
+	}
+
+	return indexes, next
+}
+
+// checkPage returns an error if the cursor or limit of a page of indexes are invalid.
+func checkPage(cursor, limit, count uint64, name string) error {
+	if cursor > count {
+		return errors.Errorf("cursor too large for %v count (max: %v)", name, count)
+	}
+
+	if limit == 0 {
+		return errors.New("page limit must be positive")
+	}
+
+	return nil
+}
+
+// AppendIndexes is a method of BitVec that appends the indexes matching the given state to dst
+// and returns the extended slice, so that a buffer can be reused across calls.
+// Returns an error if state value exceeds the maximum for the BitVec.
+func (vec *BitVec) AppendIndexes(dst []uint64, state uint64) ([]uint64, error) {
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return dst, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	match, fields := equalMatchers(vec.Size, state)
+	return appendMatching(dst, vec.Data, vec.Layout, vec.Count, vec.Size, match, fields), nil
+}
+
+// IndexesPage is a method of BitVec that returns at most limit indexes matching the given state,
+// starting at the index cursor, and the cursor of the next page. The next cursor is the Count
+// once there are no more matching indexes, so a first page is requested with a cursor of 0 and
+// pages are requested until the next cursor is the Count.
+// Returns an error if state value exceeds the maximum for the BitVec, if the cursor is beyond the Count
+// or if the limit is zero.
+func (vec *BitVec) IndexesPage(state, cursor, limit uint64) ([]uint64, uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return nil, 0, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	if err := checkPage(cursor, limit, vec.Count, "bitvec"); err != nil {
+		return nil, 0, err
+	}
+
+	indexes, next := indexesPage(vec.Data, vec.Layout, vec.Count, vec.Size, state, cursor, limit)
+	return indexes, next, nil
+}
+
+// AppendIndexes is a method of DiBit that appends the indexes matching the given state to dst
+// and returns the extended slice, so that a buffer can be reused across calls.
+// Returns an error if state value exceeds the maximum for the DiBit.
+func (vec *DiBit) AppendIndexes(dst []uint64, state uint64) ([]uint64, error) {
+	// Check for state value too large for DiBit
+	if state > vec.MaxState() {
+		return dst, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	match, fields := equalMatchers(DIBITSIZE, state)
+	return appendMatching(dst, vec.Data, vec.Layout, vec.Count, DIBITSIZE, match, fields), nil
+}
+
+// IndexesPage is a method of DiBit that returns at most limit indexes matching the given state,
+// starting at the index cursor, and the cursor of the next page. The next cursor is the Count
+// once there are no more matching indexes, so a first page is requested with a cursor of 0 and
+// pages are requested until the next cursor is the Count.
+// Returns an error if state value exceeds the maximum for the DiBit, if the cursor is beyond the Count
+// or if the limit is zero.
+func (vec *DiBit) IndexesPage(state, cursor, limit uint64) ([]uint64, uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for state value too large for DiBit
+	if state > vec.MaxState() {
+		return nil, 0, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	if err := checkPage(cursor, limit, vec.Count, "dibit"); err != nil {
+		return nil, 0, err
+	}
+
+	indexes, next := indexesPage(vec.Data, vec.Layout, vec.Count, DIBITSIZE, state, cursor, limit)
+	return indexes, next, nil
+}
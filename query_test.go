@@ -0,0 +1,202 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filterStates returns the indexes of the states that satisfy the predicate.
+func filterStates(states []uint64, predicate func(uint64) bool) []uint64 {
+	indexes := make([]uint64, 0)
+	for i, state := range states {
+		if predicate(state) {
+			indexes = append(indexes, uint64(i))
+		}
+	}
+
+	return indexes
+}
+
+func TestBitVec_IndexesInRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(46))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 3, 4, 8, 16, 64} {
+			vec := randomBitVec(rng, 300, size, layout)
+			if size > 4 {
+				// Keep the states small so that ranges match some of them
+				for i := uint64(0); i < vec.Count; i++ {
+					state, _ := vec.State(i)
+					require.Nil(t, vec.Unset(i))
+					require.Nil(t, vec.Set(i, state%20))
+				}
+
+				require.Nil(t, vec.Set(299, vec.MaxState()))
+			}
+
+			states := vec.ToStates()
+			for _, bounds := range [][2]uint64{{0, 0}, {1, 1}, {0, 1}, {2, 7}, {5, 15}, {3, 1 << 63}, {0, vec.MaxState()}, {vec.MaxState(), vec.MaxState()}} {
+				lo, hi := bounds[0], bounds[1]
+				expected := filterStates(states, func(state uint64) bool { return state >= lo && state <= hi })
+				assert.Equal(t, expected, vec.IndexesInRange(lo, hi), "size %v range [%v, %v]", size, lo, hi)
+			}
+
+			for _, masks := range [][2]uint64{{0, 0}, {1, 1}, {1, 0}, {3, 2}, {vec.MaxState(), 7 & vec.MaxState()}} {
+				mask, value := masks[0]&vec.MaxState(), masks[1]&vec.MaxState()
+				expected := filterStates(states, func(state uint64) bool { return state&mask == value })
+
+				indexes, err := vec.IndexesMasked(mask, value)
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, expected, indexes, "size %v mask %v value %v", size, mask, value)
+			}
+
+			odd := func(state uint64) bool { return state%2 == 1 }
+			assert.Equal(t, filterStates(states, odd), vec.IndexesWhere(odd))
+		}
+	}
+
+	// The padding of the last word never matches
+	vec, _ := NewBitVec(10, 4)
+	assert.Equal(t, rangeIndexes(10), vec.IndexesInRange(0, 3))
+
+	indexes, err := vec.IndexesMasked(8, 0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, rangeIndexes(10), indexes)
+
+	assert.Equal(t, []uint64{}, vec.IndexesInRange(5, 4))
+	assert.Equal(t, []uint64{}, vec.IndexesInRange(16, 20))
+
+	_, err = vec.IndexesMasked(16, 0)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 15)")
+}
+
+func TestDiBit_IndexesInRange(t *testing.T) {
+	vec := NewDiBit(40)
+	for i := uint64(0); i < 40; i++ {
+		require.Nil(t, vec.Set(i, i%4))
+	}
+
+	assert.Equal(t, []uint64{1, 2, 5, 6, 9, 10}, vec.IndexesInRange(1, 2)[:6])
+	assert.Equal(t, 20, len(vec.IndexesInRange(2, 10)))
+	assert.Equal(t, []uint64{}, vec.IndexesInRange(4, 10))
+
+	indexes, err := vec.IndexesMasked(1, 1)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{1, 3, 5, 7}, indexes[:4])
+
+	assert.Equal(t, []uint64{0, 4, 8}, vec.IndexesWhere(func(state uint64) bool { return state == 0 })[:3])
+
+	_, err = vec.IndexesMasked(1, 4)
+	assert.EqualError(t, err, "state too large for dibit state (max: 3)")
+}
+
+func TestBitVec_AppendIndexes(t *testing.T) {
+	rng := rand.New(rand.NewSource(47))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 5, 8} {
+			vec := randomBitVec(rng, 1000, size, layout)
+
+			for _, state := range []uint64{0, 1} {
+				expected, _ := vec.Indexes(state)
+
+				// The buffer is reused if it has the capacity
+				buf := make([]uint64, 2, 1000)
+				buf[0], buf[1] = 7, 7
+				indexes, err := vec.AppendIndexes(buf, state)
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, append([]uint64{7, 7}, expected...), indexes)
+				assert.Equal(t, &buf[0], &indexes[0])
+
+				// Paging collects the same indexes
+				paged := make([]uint64, 0)
+				cursor, pages := uint64(0), 0
+				for cursor < vec.Count {
+					page, next, err := vec.IndexesPage(state, cursor, 37)
+					require.Nil(t, err, "Unexpected Error")
+					require.LessOrEqual(t, len(page), 37)
+
+					paged = append(paged, page...)
+					cursor = next
+					pages++
+				}
+
+				assert.Equal(t, expected, paged)
+				assert.Equal(t, (len(expected)+36)/37, pages)
+			}
+		}
+	}
+}
+
+func TestBitVec_IndexesPage(t *testing.T) {
+	vec, _ := NewBitVec(100, 3)
+	for _, index := range []uint64{4, 21, 22, 80} {
+		require.Nil(t, vec.Set(index, 5))
+	}
+
+	tests := []struct {
+		cursor, limit uint64
+		indexes       []uint64
+		next          uint64
+	}{
+		{0, 2, []uint64{4, 21}, 22},
+		{22, 2, []uint64{22, 80}, 100},
+		{5, 10, []uint64{21, 22, 80}, 100},
+		{81, 10, []uint64{}, 100},
+		{100, 10, []uint64{}, 100},
+	}
+
+	for _, test := range tests {
+		indexes, next, err := vec.IndexesPage(5, test.cursor, test.limit)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, test.indexes, indexes)
+		assert.Equal(t, test.next, next)
+	}
+
+	_, _, err := vec.IndexesPage(8, 0, 10)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 7)")
+
+	_, _, err = vec.IndexesPage(5, 101, 10)
+	assert.EqualError(t, err, "cursor too large for bitvec count (max: 100)")
+
+	page, next, err := vec.IndexesPage(5, 0, 1<<62)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{4, 21, 22, 80}, page)
+	assert.Equal(t, uint64(100), next)
+
+	_, _, err = vec.IndexesPage(5, 0, 0)
+	assert.EqualError(t, err, "page limit must be positive")
+
+	_, err = vec.AppendIndexes(nil, 8)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 7)")
+}
+
+func TestDiBit_AppendIndexes(t *testing.T) {
+	vec := NewDiBit(100)
+	for _, index := range []uint64{4, 21, 22, 80} {
+		require.Nil(t, vec.Set(index, 3))
+	}
+
+	indexes, err := vec.AppendIndexes([]uint64{1}, 3)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{1, 4, 21, 22, 80}, indexes)
+
+	page, next, err := vec.IndexesPage(3, 5, 2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{21, 22}, page)
+	assert.Equal(t, uint64(80), next)
+
+	page, next, err = vec.IndexesPage(3, next, 2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{80}, page)
+	assert.Equal(t, uint64(100), next)
+
+	_, err = vec.AppendIndexes(nil, 4)
+	assert.EqualError(t, err, "state too large for dibit state (max: 3)")
+
+	_, _, err = vec.IndexesPage(3, 101, 1)
+	assert.EqualError(t, err, "cursor too large for dibit count (max: 100)")
+}
@@ -0,0 +1,309 @@
+package bitvec
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// MAXRANKSIZE is the maximum Size of a vector that supports Rank and Select queries.
+const MAXRANKSIZE = 2
+
+// RANKBLOCKWORDS is the number of words of Data covered by one entry of a rank directory.
+const RANKBLOCKWORDS = 8
+
+// rankDirectory is an auxiliary index over the Data of a vector that
+// stores the number of occurrences of every state before every block
+// of RANKBLOCKWORDS words, so that Rank and Select only need to scan
+// a single block of words.
+type rankDirectory struct {
+	// counts holds for every state the cumulative number of occurrences
+	// before each block, with the total number of occurrences at the end.
+	counts [][]uint64
+	// stale is set when the Data was modified after the directory was built
+	stale bool
+}
+
+// newRankDirectory builds a rankDirectory over count slots of size bits packed into data.
+func newRankDirectory(data []uint64, layout Layout, count, size uint64) *rankDirectory {
+	blocks := (len(data) + RANKBLOCKWORDS - 1) / RANKBLOCKWORDS
+	used := count * size
+
+	// Count the occurrences of every state within each block
+	counts := make([][]uint64, bitMask(size)+1)
+	for state := range counts {
+		counts[state] = make([]uint64, blocks+1)
+		for w := range data {
+			matches := wordMatches(data, layout, size, used, w, uint64(state))
+			counts[state][w/RANKBLOCKWORDS+1] += uint64(bits.OnesCount64(matches))
+		}
+
+		// Accumulate the counts across blocks
+		for block := 1; block <= blocks; block++ {
+			counts[state][block] += counts[state][block-1]
+		}
+	}
+
+	return &rankDirectory{counts: counts}
+}
+
+// overhead returns the number of bytes of memory used by the rankDirectory.
+func (dir *rankDirectory) overhead() uint64 {
+	if dir == nil {
+		return 0
+	}
+
+	var total uint64
+	for _, counts := range dir.counts {
+		total += uint64(len(counts)) * 8
+	}
+
+	return total
+}
+
+// rank returns the number of occurrences of state before index in count slots of size
+// bits packed into data. The rankDirectory may be nil, in which case all words are scanned.
+func rank(data []uint64, layout Layout, count, size uint64, dir *rankDirectory, state, index uint64) uint64 {
+	pos, used := index*size, count*size
+	word := int(pos / 64)
+
+	// Start from the block containing the word if there is a directory
+	var total uint64
+	var start int
+	if dir != nil {
+		block := word / RANKBLOCKWORDS
+		total, start = dir.counts[state][block], block*RANKBLOCKWORDS
+	}
+
+	// Count the occurrences in every whole word before the index
+	for w := start; w < word; w++ {
+		total += uint64(bits.OnesCount64(wordMatches(data, layout, size, used, w, state)))
+	}
+
+	// Count the occurrences in the word containing the index
+	if offset := pos % 64; offset != 0 {
+		matches := wordMatches(data, layout, size, used, word, state) & leadingBits(layout, offset)
+		total += uint64(bits.OnesCount64(matches))
+	}
+
+	return total
+}
+
+// selectIndex returns the index of the k-th (counting from 0) occurrence of state in count slots of size
+// bits packed into data and whether it exists. The rankDirectory may be nil, in which case the words are scanned.
+func selectIndex(data []uint64, layout Layout, count, size uint64, dir *rankDirectory, state, k uint64) (uint64, bool) {
+	used := count * size
+
+	// Start from the last block with at most k occurrences before it if there is a directory
+	var start int
+	if dir != nil {
+		counts := dir.counts[state]
+		block := sort.Search(len(counts), func(block int) bool { return counts[block] > k }) - 1
+		k, start = k-counts[block], block*RANKBLOCKWORDS
+	}
+
+	// Scan the words until the one containing the occurrence
+	for w := start; w < len(data); w++ {
+		matches := wordMatches(data, layout, size, used, w, state)
+		if n := uint64(bits.OnesCount64(matches)); k >= n {
+			k -= n
+			continue
+		}
+
+		// Skip over the earlier occurrences within the word
+		var bit int
+		for ; ; k-- {
+			bit, matches = firstField(layout, matches)
+			if k == 0 {
+				break
+			}
+		}
+
+		return uint64(w)*(64/size) + fieldSlot(layout, size, bit), true
+	}
+
+	return 0, false
+}
+
+// BuildRank is a method of BitVec that builds an auxiliary rank directory over the Data,
+// which makes Rank constant time and Select logarithmic time in the Count. The directory is
+// invalidated by any modification and rebuilt on the next query. Its memory overhead is
+// reported by RankOverhead. Returns an error if Size is greater than MAXRANKSIZE.
+func (vec *BitVec) BuildRank() error {
+	// Check for state size unsupported by rank queries
+	if vec.Size == 0 || vec.Size > MAXRANKSIZE {
+		return errors.Errorf("rank queries unsupported for bitvec size (max: %v)", MAXRANKSIZE)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.rank = newRankDirectory(vec.Data, vec.Layout, vec.Count, vec.Size)
+	return nil
+}
+
+// DropRank is a method of BitVec that discards the auxiliary rank directory, if any.
+func (vec *BitVec) DropRank() {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.rank = nil
+}
+
+// RankOverhead is a method of BitVec that returns the number of bytes
+// of memory used by the auxiliary rank directory, or 0 if there is none.
+func (vec *BitVec) RankOverhead() uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.rank.overhead()
+}
+
+// Rank is a method of BitVec that returns the number of occurrences of the given state before the given index.
+// Returns an error if Size is greater than MAXRANKSIZE, if the index is greater than the Count
+// or if the state value exceeds the maximum for the BitVec.
+func (vec *BitVec) Rank(state, index uint64) (uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for state size unsupported by rank queries
+	if vec.Size == 0 || vec.Size > MAXRANKSIZE {
+		return 0, errors.Errorf("rank queries unsupported for bitvec size (max: %v)", MAXRANKSIZE)
+	}
+
+	// Check for out of bounds index
+	if index > vec.Count {
+		return 0, errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return 0, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Rebuild the rank directory if it is out of date
+	if vec.rank != nil && vec.rank.stale {
+		vec.rank = newRankDirectory(vec.Data, vec.Layout, vec.Count, vec.Size)
+	}
+
+	return rank(vec.Data, vec.Layout, vec.Count, vec.Size, vec.rank, state, index), nil
+}
+
+// Select is a method of BitVec that returns the index of the k-th occurrence (counting from 0) of the given state.
+// Returns an error if Size is greater than MAXRANKSIZE, if the state value exceeds
+// the maximum for the BitVec or if there are not more than k occurrences of the state.
+func (vec *BitVec) Select(state, k uint64) (uint64, error) {
+	// Check for state size unsupported by rank queries
+	if vec.Size == 0 || vec.Size > MAXRANKSIZE {
+		return 0, errors.Errorf("rank queries unsupported for bitvec size (max: %v)", MAXRANKSIZE)
+	}
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return 0, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Rebuild the rank directory if it is out of date
+	if vec.rank != nil && vec.rank.stale {
+		vec.rank = newRankDirectory(vec.Data, vec.Layout, vec.Count, vec.Size)
+	}
+
+	index, ok := selectIndex(vec.Data, vec.Layout, vec.Count, vec.Size, vec.rank, state, k)
+	if !ok {
+		total := rank(vec.Data, vec.Layout, vec.Count, vec.Size, vec.rank, state, vec.Count)
+		return 0, errors.Errorf("not enough occurrences of state for select (count: %v)", total)
+	}
+
+	return index, nil
+}
+
+// BuildRank is a method of DiBit that builds an auxiliary rank directory over the Data,
+// which makes Rank constant time and Select logarithmic time in the Count. The directory is
+// invalidated by any modification and rebuilt on the next query. Its memory overhead is
+// reported by RankOverhead.
+func (vec *DiBit) BuildRank() {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.rank = newRankDirectory(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+}
+
+// DropRank is a method of DiBit that discards the auxiliary rank directory, if any.
+func (vec *DiBit) DropRank() {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.rank = nil
+}
+
+// RankOverhead is a method of DiBit that returns the number of bytes
+// of memory used by the auxiliary rank directory, or 0 if there is none.
+func (vec *DiBit) RankOverhead() uint64 {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.rank.overhead()
+}
+
+// Rank is a method of DiBit that returns the number of occurrences of the given state before the given index.
+// Returns an error if the index is greater than the Count or if the state value exceeds the maximum for the DiBit.
+func (vec *DiBit) Rank(state, index uint64) (uint64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index > vec.Count {
+		return 0, errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for DiBit
+	if state > vec.MaxState() {
+		return 0, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	// Rebuild the rank directory if it is out of date
+	if vec.rank != nil && vec.rank.stale {
+		vec.rank = newRankDirectory(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+	}
+
+	return rank(vec.Data, vec.Layout, vec.Count, DIBITSIZE, vec.rank, state, index), nil
+}
+
+// Select is a method of DiBit that returns the index of the k-th occurrence (counting from 0) of the given state.
+// Returns an error if the state value exceeds the maximum for the DiBit or if there are not more than k occurrences of the state.
+func (vec *DiBit) Select(state, k uint64) (uint64, error) {
+	// Check for state value too large for DiBit
+	if state > vec.MaxState() {
+		return 0, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Rebuild the rank directory if it is out of date
+	if vec.rank != nil && vec.rank.stale {
+		vec.rank = newRankDirectory(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+	}
+
+	index, ok := selectIndex(vec.Data, vec.Layout, vec.Count, DIBITSIZE, vec.rank, state, k)
+	if !ok {
+		total := rank(vec.Data, vec.Layout, vec.Count, DIBITSIZE, vec.rank, state, vec.Count)
+		return 0, errors.Errorf("not enough occurrences of state for select (count: %v)", total)
+	}
+
+	return index, nil
+}
@@ -0,0 +1,131 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// naiveRank counts the occurrences of state before index by comparing every slot.
+func naiveRank(states []uint64, state, index uint64) uint64 {
+	var total uint64
+	for _, value := range states[:index] {
+		if value == state {
+			total++
+		}
+	}
+
+	return total
+}
+
+func TestBitVec_Rank(t *testing.T) {
+	rng := rand.New(rand.NewSource(29))
+
+	for _, size := range []uint64{1, 2} {
+		for _, layout := range []Layout{MSBFirst, LSBFirst} {
+			vec, err := NewBitVecWithLayout(1500, size, layout)
+			require.Nil(t, err, "Unexpected Error")
+
+			states := make([]uint64, vec.Count)
+			for i := range states {
+				states[i] = uint64(rng.Intn(int(vec.MaxState()) + 1))
+				require.Nil(t, vec.Set(uint64(i), states[i]))
+			}
+
+			for _, build := range []bool{false, true} {
+				if build {
+					require.Nil(t, vec.BuildRank())
+					assert.NotZero(t, vec.RankOverhead())
+				}
+
+				for state := uint64(0); state <= vec.MaxState(); state++ {
+					for _, index := range []uint64{0, 1, 63, 64, 511, 512, 513, 1000, 1499, 1500} {
+						rank, err := vec.Rank(state, index)
+						require.Nil(t, err, "Unexpected Error")
+						assert.Equal(t, naiveRank(states, state, index), rank)
+					}
+
+					total := naiveRank(states, state, vec.Count)
+					for k := uint64(0); k < total; k += 7 {
+						index, err := vec.Select(state, k)
+						require.Nil(t, err, "Unexpected Error")
+						assert.Equal(t, state, states[index])
+						assert.Equal(t, k, naiveRank(states, state, index))
+					}
+
+					_, err := vec.Select(state, total)
+					assert.Error(t, err)
+				}
+			}
+
+			// Modifications invalidate the directory, which is rebuilt on the next query
+			require.Nil(t, vec.Unset(700))
+			states[700] = 0
+
+			rank, err := vec.Rank(0, vec.Count)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, naiveRank(states, 0, vec.Count), rank)
+
+			vec.DropRank()
+			assert.Zero(t, vec.RankOverhead())
+		}
+	}
+}
+
+func TestBitVec_RankErrors(t *testing.T) {
+	vec := &BitVec{Count: 10, Size: 4, Data: []uint64{0}}
+	_, err := vec.Rank(0, 0)
+	assert.EqualError(t, err, "rank queries unsupported for bitvec size (max: 2)")
+	_, err = vec.Select(0, 0)
+	assert.EqualError(t, err, "rank queries unsupported for bitvec size (max: 2)")
+	assert.EqualError(t, vec.BuildRank(), "rank queries unsupported for bitvec size (max: 2)")
+
+	vec = &BitVec{Count: 10, Size: 1, Data: []uint64{0}}
+	_, err = vec.Rank(0, 11)
+	assert.EqualError(t, err, "index too large for bitvec count (max: 10)")
+	_, err = vec.Rank(2, 0)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 1)")
+	_, err = vec.Select(1, 0)
+	assert.EqualError(t, err, "not enough occurrences of state for select (count: 0)")
+}
+
+func TestDiBit_Rank(t *testing.T) {
+	vec := &DiBit{Count: 33, Data: []uint64{1059, 4611686018427387904}}
+	vec.BuildRank()
+	assert.Equal(t, uint64(4*2*8), vec.RankOverhead())
+
+	tests := []struct {
+		state, index, rank uint64
+	}{
+		{0, 33, 29},
+		{1, 33, 2},
+		{2, 33, 1},
+		{3, 33, 1},
+		{3, 31, 0},
+		{3, 32, 1},
+		{1, 32, 1},
+	}
+
+	for _, test := range tests {
+		rank, err := vec.Rank(test.state, test.index)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, test.rank, rank)
+	}
+
+	index, err := vec.Select(3, 0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(31), index)
+
+	index, err = vec.Select(1, 1)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(32), index)
+
+	require.Nil(t, vec.Unset(32))
+	_, err = vec.Select(1, 1)
+	assert.EqualError(t, err, "not enough occurrences of state for select (count: 1)")
+
+	_, err = vec.Rank(0, 34)
+	assert.EqualError(t, err, "index too large for dibit count (max: 33)")
+}
@@ -0,0 +1,299 @@
+package bitvec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Run is a run of consecutive responses with the same state in an RLEVec.
+type Run struct {
+	// Start is the index of the first response of the run
+	Start uint64
+	// State is the state of every response in the run
+	State uint64
+}
+
+// RLEVec is a struct that maintains some number of responses as runs of the
+// same state. It supports the same operations as BitVec, but its memory use
+// depends on the number of runs rather than on the number of responses.
+type RLEVec struct {
+	// mu is the thread safety mutex
+	mu sync.Mutex
+
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+	// Runs stores the runs of responses sorted by their start. Every run extends
+	// to the start of the next run (or the Count) and differs in state from it.
+	Runs []Run
+}
+
+// NewRLEVec is a constructor function for RLEVec.
+// Returns an error if Size is greater than MAXVECSIZE
+func NewRLEVec(count, size uint64) (*RLEVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	vec := &RLEVec{mu: sync.Mutex{}, Count: count, Size: size, Runs: make([]Run, 0, 1)}
+	if count > 0 {
+		vec.Runs = append(vec.Runs, Run{Start: 0, State: 0})
+	}
+
+	return vec, nil
+}
+
+// newRLEVecFromData is a constructor function for RLEVec from count slots of size bits packed into data.
+func newRLEVecFromData(data []uint64, layout Layout, count, size uint64) *RLEVec {
+	vec := &RLEVec{mu: sync.Mutex{}, Count: count, Size: size, Runs: make([]Run, 0, 1)}
+
+	reader := slotReader{data: data, layout: layout, size: size}
+	for i := uint64(0); i < count; i++ {
+		// Start a new run whenever the state changes
+		if state := reader.read(); i == 0 || state != vec.Runs[len(vec.Runs)-1].State {
+			vec.Runs = append(vec.Runs, Run{Start: i, State: state})
+		}
+	}
+
+	return vec
+}
+
+// NewRLEVecFromBitVec is a constructor function for RLEVec with the responses of a BitVec.
+func NewRLEVecFromBitVec(vec *BitVec) *RLEVec {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return newRLEVecFromData(vec.Data, vec.Layout, vec.Count, vec.Size)
+}
+
+// NewRLEVecFromDiBit is a constructor function for RLEVec with the responses of a DiBit.
+func NewRLEVecFromDiBit(vec *DiBit) *RLEVec {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return newRLEVecFromData(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+}
+
+// String implements the Stringer interface for RLEVec
+func (vec *RLEVec) String() string {
+	return fmt.Sprintf("[%v|%v] %v", vec.Count, vec.Size, vec.Runs)
+}
+
+// MaxState is a method of RLEVec that returns the maximum value for a state for that RLEVec.
+// It is calculated as 2^StateBits-1.
+func (vec *RLEVec) MaxState() uint64 {
+	return 1<<vec.Size - 1
+}
+
+// pack writes the states of all responses into data in the given layout.
+func (vec *RLEVec) pack(data []uint64, layout Layout) {
+	writer := slotWriter{data: data, layout: layout, size: vec.Size}
+	for r, run := range vec.Runs {
+		end := vec.Count
+		if r+1 < len(vec.Runs) {
+			end = vec.Runs[r+1].Start
+		}
+
+		for i := run.Start; i < end; i++ {
+			writer.write(run.State)
+		}
+	}
+
+	writer.flush()
+}
+
+// ToBitVec is a method of RLEVec that returns a BitVec with the same responses.
+func (vec *RLEVec) ToBitVec() *BitVec {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Error can be ignored because the Size has already been checked
+	output, _ := NewBitVec(vec.Count, vec.Size)
+	vec.pack(output.Data, output.Layout)
+
+	return output
+}
+
+// ToDiBit is a method of RLEVec that returns a DiBit with the same responses.
+// Returns an error if the Size is not DIBITSIZE.
+func (vec *RLEVec) ToDiBit() (*DiBit, error) {
+	// Check for a Size other than a DiBit
+	if vec.Size != DIBITSIZE {
+		return nil, errors.Errorf("rlevec size does not match dibit size (size: %v)", vec.Size)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	output := NewDiBit(vec.Count)
+	vec.pack(output.Data, output.Layout)
+
+	return output, nil
+}
+
+// find returns the position in Runs of the run containing the given index.
+func (vec *RLEVec) find(index uint64) int {
+	return sort.Search(len(vec.Runs), func(r int) bool { return vec.Runs[r].Start > index }) - 1
+}
+
+// assign sets the state of the response at the given index,
+// splitting the run containing it and merging equal neighbouring runs.
+func (vec *RLEVec) assign(index, state uint64) {
+	r := vec.find(index)
+	run := vec.Runs[r]
+	if run.State == state {
+		return
+	}
+
+	end := vec.Count
+	if r+1 < len(vec.Runs) {
+		end = vec.Runs[r+1].Start
+	}
+
+	// Split the run into the part before the index, the index and the part after it
+	pieces := make([]Run, 0, 3)
+	if index > run.Start {
+		pieces = append(pieces, run)
+	}
+
+	pieces = append(pieces, Run{Start: index, State: state})
+	if index+1 < end {
+		pieces = append(pieces, Run{Start: index + 1, State: run.State})
+	}
+
+	vec.Runs = append(vec.Runs[:r], append(pieces, vec.Runs[r+1:]...)...)
+
+	// Merge the runs around the pieces that have the same state
+	lo, hi := r, r+len(pieces)+1
+	if lo > 0 {
+		lo--
+	}
+
+	if hi > len(vec.Runs) {
+		hi = len(vec.Runs)
+	}
+
+	last := lo
+	for i := lo + 1; i < hi; i++ {
+		if vec.Runs[i].State != vec.Runs[last].State {
+			last++
+			vec.Runs[last] = vec.Runs[i]
+		}
+	}
+
+	vec.Runs = append(vec.Runs[:last+1], vec.Runs[hi:]...)
+}
+
+// Set is a method of RLEVec that sets a given state at given index.
+// Like BitVec.Set, the state is merged into the existing state of the response.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the RLEVec.
+func (vec *RLEVec) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for rlevec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for RLEVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for rlevec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.assign(index, vec.Runs[vec.find(index)].State|state)
+	return nil
+}
+
+// Unset is a method of RLEVec that unsets the state for a given index.
+// Returns an error index is out of bounds.
+func (vec *RLEVec) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for rlevec count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.assign(index, 0)
+	return nil
+}
+
+// Has is a method of RLEVec that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the RLEVec.
+func (vec *RLEVec) Has(index, state uint64) (bool, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return false, errors.Errorf("index too large for rlevec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for RLEVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for rlevec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.Runs[vec.find(index)].State == state, nil
+}
+
+// State is a method of RLEVec that returns the state at a given index.
+// Returns an error if the index is out of bounds.
+func (vec *RLEVec) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for rlevec count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.Runs[vec.find(index)].State, nil
+}
+
+// Indexes is a method of RLEVec that returns the slice of indexes matching the given state.
+// Returns an error if state value exceeds the maximum for the RLEVec.
+func (vec *RLEVec) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for RLEVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for rlevec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Append every index of the runs with the state
+	indexes := make([]uint64, 0)
+	for r, run := range vec.Runs {
+		if run.State != state {
+			continue
+		}
+
+		end := vec.Count
+		if r+1 < len(vec.Runs) {
+			end = vec.Runs[r+1].Start
+		}
+
+		for i := run.Start; i < end; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
@@ -0,0 +1,186 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRLEVec(t *testing.T) {
+	vec, err := NewRLEVec(100, 2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []Run{{0, 0}}, vec.Runs)
+	assert.Equal(t, "[100|2] [{0 0}]", vec.String())
+
+	vec, err = NewRLEVec(0, 2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []Run{}, vec.Runs)
+
+	vec, err = NewRLEVec(100, 70)
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+	assert.Nil(t, vec)
+}
+
+func TestRLEVec_Set(t *testing.T) {
+	tests := []struct {
+		rlevec   *RLEVec
+		idx, val uint64
+		output   []Run
+		err      string
+	}{
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 0}}},
+			4, 3, []Run{{0, 0}, {4, 3}, {5, 0}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 0}}},
+			0, 3, []Run{{0, 3}, {1, 0}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 0}}},
+			9, 1, []Run{{0, 0}, {9, 1}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 1}, {4, 0}, {5, 1}}},
+			4, 1, []Run{{0, 1}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 1}, {4, 0}, {6, 3}}},
+			5, 3, []Run{{0, 1}, {4, 0}, {5, 3}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 1}, {4, 2}}},
+			2, 2, []Run{{0, 1}, {2, 3}, {3, 1}, {4, 2}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 0}}},
+			10, 1, []Run{{0, 0}}, "index too large for rlevec count (max: 10)",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 0}}},
+			3, 4, []Run{{0, 0}}, "state too large for rlevec state (max: 3)",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.rlevec.Set(test.idx, test.val)
+		assert.Equal(t, test.output, test.rlevec.Runs)
+
+		if test.err == "" {
+			assert.Nil(t, err, "Unexpected Error")
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestRLEVec_Unset(t *testing.T) {
+	tests := []struct {
+		rlevec *RLEVec
+		idx    uint64
+		output []Run
+		err    string
+	}{
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 0}, {4, 3}, {5, 0}}},
+			4, []Run{{0, 0}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 2}}},
+			9, []Run{{0, 2}, {9, 0}}, "",
+		},
+		{
+			&RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 2}}},
+			10, []Run{{0, 2}}, "index too large for rlevec count (max: 10)",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.rlevec.Unset(test.idx)
+		assert.Equal(t, test.output, test.rlevec.Runs)
+
+		if test.err == "" {
+			assert.Nil(t, err, "Unexpected Error")
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestRLEVec_State(t *testing.T) {
+	vec := &RLEVec{Count: 10, Size: 2, Runs: []Run{{0, 1}, {4, 0}, {6, 3}}}
+
+	for i, expected := range []uint64{1, 1, 1, 1, 0, 0, 3, 3, 3, 3} {
+		state, err := vec.State(uint64(i))
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, expected, state)
+
+		exists, err := vec.Has(uint64(i), expected)
+		require.Nil(t, err, "Unexpected Error")
+		assert.True(t, exists)
+	}
+
+	_, err := vec.State(10)
+	assert.EqualError(t, err, "index too large for rlevec count (max: 10)")
+
+	_, err = vec.Has(1, 4)
+	assert.EqualError(t, err, "state too large for rlevec state (max: 3)")
+
+	indexes, err := vec.Indexes(3)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{6, 7, 8, 9}, indexes)
+
+	indexes, err = vec.Indexes(2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{}, indexes)
+
+	indexes, err = vec.Indexes(4)
+	assert.EqualError(t, err, "state too large for rlevec state (max: 3)")
+	assert.Nil(t, indexes)
+}
+
+func TestRLEVec_Convert(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+
+	vec, err := NewBitVecWithLayout(500, 3, LSBFirst)
+	require.Nil(t, err, "Unexpected Error")
+
+	for i := uint64(0); i < vec.Count; i += 1 + uint64(rng.Intn(40)) {
+		require.Nil(t, vec.Set(i, uint64(rng.Intn(8))))
+	}
+
+	rle := NewRLEVecFromBitVec(vec)
+	assert.Equal(t, vec.ToStates(), rle.ToBitVec().ToStates())
+
+	// Random writes must keep the runs in sync with the BitVec
+	for n := 0; n < 300; n++ {
+		index, state := uint64(rng.Intn(500)), uint64(rng.Intn(8))
+		if n%3 == 0 {
+			require.Nil(t, vec.Unset(index))
+			require.Nil(t, rle.Unset(index))
+		} else {
+			require.Nil(t, vec.Set(index, state))
+			require.Nil(t, rle.Set(index, state))
+		}
+	}
+
+	assert.Equal(t, vec.ToStates(), rle.ToBitVec().ToStates())
+	for r := 1; r < len(rle.Runs); r++ {
+		assert.NotEqual(t, rle.Runs[r-1].State, rle.Runs[r].State)
+	}
+
+	dibit := NewDiBit(100)
+	require.Nil(t, dibit.Set(50, 2))
+
+	rle = NewRLEVecFromDiBit(dibit)
+	assert.Equal(t, []Run{{0, 0}, {50, 2}, {51, 0}}, rle.Runs)
+
+	output, err := rle.ToDiBit()
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, dibit.Data, output.Data)
+
+	_, err = NewRLEVecFromBitVec(vec).ToDiBit()
+	assert.EqualError(t, err, "rlevec size does not match dibit size (size: 3)")
+}
@@ -0,0 +1,683 @@
+package bitvec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The constants of the portable Roaring serialization format.
+// See https://github.com/RoaringBitmap/RoaringFormatSpec for details.
+const (
+	// roaringCookie starts a bitmap with at least one run container
+	roaringCookie = 12347
+	// roaringCookieNoRuns starts a bitmap without run containers
+	roaringCookieNoRuns = 12346
+	// roaringNoOffsetThreshold is the number of containers below which
+	// a bitmap with run containers omits the offset header
+	roaringNoOffsetThreshold = 4
+	// roaringArrayMax is the largest cardinality stored in an array container
+	roaringArrayMax = 4096
+	// roaringBitmapWords is the number of words of a bitmap container
+	roaringBitmapWords = 1024
+)
+
+// roaringContainer holds the low 16 bits of the values of a roaringBitmap in one 65536 value chunk.
+type roaringContainer interface {
+	// cardinality returns the number of values in the container
+	cardinality() int
+	// runs returns the number of runs of consecutive values in the container
+	runs() int
+	// contains returns whether the value is in the container
+	contains(low uint16) bool
+	// add adds the value and returns the container, which may have changed representation
+	add(low uint16) roaringContainer
+	// remove removes the value and returns the container, which may have changed representation
+	remove(low uint16) roaringContainer
+	// words returns the values of the container as a bitmap
+	words() *[roaringBitmapWords]uint64
+}
+
+// arrayContainer is a roaringContainer that stores the sorted values.
+type arrayContainer struct {
+	values []uint16
+}
+
+func (c *arrayContainer) cardinality() int {
+	return len(c.values)
+}
+
+func (c *arrayContainer) runs() int {
+	total := 0
+	for i, value := range c.values {
+		if i == 0 || value != c.values[i-1]+1 {
+			total++
+		}
+	}
+
+	return total
+}
+
+func (c *arrayContainer) search(low uint16) (int, bool) {
+	i := sort.Search(len(c.values), func(i int) bool { return c.values[i] >= low })
+	return i, i < len(c.values) && c.values[i] == low
+}
+
+func (c *arrayContainer) contains(low uint16) bool {
+	_, found := c.search(low)
+	return found
+}
+
+func (c *arrayContainer) add(low uint16) roaringContainer {
+	i, found := c.search(low)
+	if found {
+		return c
+	}
+
+	c.values = append(c.values, 0)
+	copy(c.values[i+1:], c.values[i:])
+	c.values[i] = low
+
+	// Switch to a bitmap once the array would use more memory than one
+	if len(c.values) > roaringArrayMax {
+		return newBitmapContainer(c.words())
+	}
+
+	return c
+}
+
+func (c *arrayContainer) remove(low uint16) roaringContainer {
+	if i, found := c.search(low); found {
+		c.values = append(c.values[:i], c.values[i+1:]...)
+	}
+
+	return c
+}
+
+func (c *arrayContainer) words() *[roaringBitmapWords]uint64 {
+	words := new([roaringBitmapWords]uint64)
+	for _, value := range c.values {
+		words[value/64] |= 1 << (value % 64)
+	}
+
+	return words
+}
+
+// bitmapContainer is a roaringContainer that stores a bit for every possible value.
+type bitmapContainer struct {
+	bitmap [roaringBitmapWords]uint64
+	card   int
+}
+
+// newBitmapContainer returns a bitmapContainer with the given words.
+func newBitmapContainer(words *[roaringBitmapWords]uint64) *bitmapContainer {
+	c := &bitmapContainer{bitmap: *words}
+	for _, word := range words {
+		c.card += bits.OnesCount64(word)
+	}
+
+	return c
+}
+
+func (c *bitmapContainer) cardinality() int {
+	return c.card
+}
+
+func (c *bitmapContainer) runs() int {
+	// Count the bits that are set without the preceding bit being set
+	total, carry := 0, uint64(0)
+	for _, word := range c.bitmap {
+		total += bits.OnesCount64(word &^ (word<<1 | carry))
+		carry = word >> 63
+	}
+
+	return total
+}
+
+func (c *bitmapContainer) contains(low uint16) bool {
+	return c.bitmap[low/64]&(1<<(low%64)) != 0
+}
+
+func (c *bitmapContainer) add(low uint16) roaringContainer {
+	if !c.contains(low) {
+		c.bitmap[low/64] |= 1 << (low % 64)
+		c.card++
+	}
+
+	return c
+}
+
+func (c *bitmapContainer) remove(low uint16) roaringContainer {
+	if c.contains(low) {
+		c.bitmap[low/64] &^= 1 << (low % 64)
+		c.card--
+	}
+
+	// Switch to an array once it is no larger than the bitmap
+	if c.card <= roaringArrayMax {
+		return newArrayContainer(&c.bitmap)
+	}
+
+	return c
+}
+
+func (c *bitmapContainer) words() *[roaringBitmapWords]uint64 {
+	words := c.bitmap
+	return &words
+}
+
+// newArrayContainer returns an arrayContainer with the values set in the given words.
+func newArrayContainer(words *[roaringBitmapWords]uint64) *arrayContainer {
+	c := &arrayContainer{values: make([]uint16, 0)}
+	for w, word := range words {
+		for ; word != 0; word &= word - 1 {
+			c.values = append(c.values, uint16(w*64+bits.TrailingZeros64(word)))
+		}
+	}
+
+	return c
+}
+
+// roaringRun is a run of consecutive values from start to last (inclusive).
+type roaringRun struct {
+	start, last uint16
+}
+
+// runContainer is a roaringContainer that stores runs of consecutive values.
+type runContainer struct {
+	values []roaringRun
+}
+
+// newRunContainer returns a runContainer with the values set in the given words.
+func newRunContainer(words *[roaringBitmapWords]uint64) *runContainer {
+	c := &runContainer{values: make([]roaringRun, 0)}
+
+	open := false
+	for i := 0; i < roaringBitmapWords*64; i++ {
+		set := words[i/64]&(1<<(i%64)) != 0
+		switch {
+		case set && !open:
+			c.values = append(c.values, roaringRun{start: uint16(i), last: uint16(i)})
+			open = true
+		case set:
+			c.values[len(c.values)-1].last = uint16(i)
+		default:
+			open = false
+		}
+	}
+
+	return c
+}
+
+func (c *runContainer) cardinality() int {
+	total := 0
+	for _, run := range c.values {
+		total += int(run.last-run.start) + 1
+	}
+
+	return total
+}
+
+func (c *runContainer) runs() int {
+	return len(c.values)
+}
+
+// search returns the position of the first run that ends at or after the value.
+func (c *runContainer) search(low uint16) int {
+	return sort.Search(len(c.values), func(i int) bool { return c.values[i].last >= low })
+}
+
+func (c *runContainer) contains(low uint16) bool {
+	i := c.search(low)
+	return i < len(c.values) && c.values[i].start <= low
+}
+
+func (c *runContainer) add(low uint16) roaringContainer {
+	i := c.search(low)
+	if i < len(c.values) && c.values[i].start <= low {
+		return c
+	}
+
+	// Extend the neighbouring runs if the value is adjacent to them
+	joinPrev := i > 0 && int(c.values[i-1].last)+1 == int(low)
+	joinNext := i < len(c.values) && int(low)+1 == int(c.values[i].start)
+
+	switch {
+	case joinPrev && joinNext:
+		c.values[i-1].last = c.values[i].last
+		c.values = append(c.values[:i], c.values[i+1:]...)
+	case joinPrev:
+		c.values[i-1].last = low
+	case joinNext:
+		c.values[i].start = low
+	default:
+		c.values = append(c.values, roaringRun{})
+		copy(c.values[i+1:], c.values[i:])
+		c.values[i] = roaringRun{start: low, last: low}
+	}
+
+	return c
+}
+
+func (c *runContainer) remove(low uint16) roaringContainer {
+	i := c.search(low)
+	if i == len(c.values) || c.values[i].start > low {
+		return c
+	}
+
+	// Shrink, drop or split the run containing the value
+	run := c.values[i]
+	switch {
+	case run.start == run.last:
+		c.values = append(c.values[:i], c.values[i+1:]...)
+	case run.start == low:
+		c.values[i].start++
+	case run.last == low:
+		c.values[i].last--
+	default:
+		c.values = append(c.values, roaringRun{})
+		copy(c.values[i+1:], c.values[i:])
+		c.values[i].last = low - 1
+		c.values[i+1].start = low + 1
+	}
+
+	return c
+}
+
+func (c *runContainer) words() *[roaringBitmapWords]uint64 {
+	words := new([roaringBitmapWords]uint64)
+	for _, run := range c.values {
+		for i := int(run.start); i <= int(run.last); i++ {
+			words[i/64] |= 1 << (i % 64)
+		}
+	}
+
+	return words
+}
+
+// optimizeContainer returns the container in the representation that uses the least memory.
+func optimizeContainer(c roaringContainer) roaringContainer {
+	card, runs := c.cardinality(), c.runs()
+
+	// A run container is only used if it is strictly smaller than the alternatives
+	runBytes := 2 + 4*runs
+	if runBytes < 2*card && runBytes < 8*roaringBitmapWords {
+		if _, ok := c.(*runContainer); !ok {
+			return newRunContainer(c.words())
+		}
+
+		return c
+	}
+
+	// Otherwise the cardinality determines the representation, as in the serialized form
+	if card <= roaringArrayMax {
+		if _, ok := c.(*arrayContainer); !ok {
+			return newArrayContainer(c.words())
+		}
+
+		return c
+	}
+
+	if _, ok := c.(*bitmapContainer); !ok {
+		return newBitmapContainer(c.words())
+	}
+
+	return c
+}
+
+// roaringBitmap is a compressed set of 32-bit values, split into chunks of 65536
+// values by their high 16 bits, each of which is stored in a roaringContainer.
+type roaringBitmap struct {
+	// keys holds the high 16 bits of the values of each container, sorted
+	keys []uint16
+	// containers holds the non-empty containers in the order of their keys
+	containers []roaringContainer
+}
+
+// newRoaringBitmap returns an empty roaringBitmap.
+func newRoaringBitmap() *roaringBitmap {
+	return &roaringBitmap{keys: make([]uint16, 0), containers: make([]roaringContainer, 0)}
+}
+
+// String implements the Stringer interface for roaringBitmap
+func (rb *roaringBitmap) String() string {
+	containers := make([]string, len(rb.keys))
+	for i, key := range rb.keys {
+		kind := "bitmap"
+		switch rb.containers[i].(type) {
+		case *arrayContainer:
+			kind = "array"
+		case *runContainer:
+			kind = "run"
+		}
+
+		containers[i] = fmt.Sprintf("%v:%v(%v)", key, kind, rb.containers[i].cardinality())
+	}
+
+	return "{" + strings.Join(containers, " ") + "}"
+}
+
+// search returns the position of the container with the given key and whether it exists.
+func (rb *roaringBitmap) search(key uint16) (int, bool) {
+	i := sort.Search(len(rb.keys), func(i int) bool { return rb.keys[i] >= key })
+	return i, i < len(rb.keys) && rb.keys[i] == key
+}
+
+// add adds the value to the roaringBitmap.
+func (rb *roaringBitmap) add(value uint32) {
+	key, low := uint16(value>>16), uint16(value)
+
+	i, found := rb.search(key)
+	if found {
+		rb.containers[i] = rb.containers[i].add(low)
+		return
+	}
+
+	// Insert a new array container for the key
+	rb.keys = append(rb.keys, 0)
+	copy(rb.keys[i+1:], rb.keys[i:])
+	rb.keys[i] = key
+
+	rb.containers = append(rb.containers, nil)
+	copy(rb.containers[i+1:], rb.containers[i:])
+	rb.containers[i] = &arrayContainer{values: []uint16{low}}
+}
+
+// remove removes the value from the roaringBitmap, dropping containers that become empty.
+func (rb *roaringBitmap) remove(value uint32) {
+	key, low := uint16(value>>16), uint16(value)
+
+	i, found := rb.search(key)
+	if !found {
+		return
+	}
+
+	rb.containers[i] = rb.containers[i].remove(low)
+	if rb.containers[i].cardinality() == 0 {
+		rb.keys = append(rb.keys[:i], rb.keys[i+1:]...)
+		rb.containers = append(rb.containers[:i], rb.containers[i+1:]...)
+	}
+}
+
+// contains returns whether the value is in the roaringBitmap.
+func (rb *roaringBitmap) contains(value uint32) bool {
+	i, found := rb.search(uint16(value >> 16))
+	return found && rb.containers[i].contains(uint16(value))
+}
+
+// chunk returns the bitmap of the container with the given key, or nil if there is none.
+func (rb *roaringBitmap) chunk(key uint16) *[roaringBitmapWords]uint64 {
+	if i, found := rb.search(key); found {
+		return rb.containers[i].words()
+	}
+
+	return nil
+}
+
+// optimize switches every container to the representation that uses the least memory.
+func (rb *roaringBitmap) optimize() {
+	for i, c := range rb.containers {
+		rb.containers[i] = optimizeContainer(c)
+	}
+}
+
+// combine returns a new roaringBitmap that holds the values of a and b combined
+// with op for every chunk, where op receives nil for a chunk without a container.
+func combineRoaring(a, b *roaringBitmap, op func(x, y *[roaringBitmapWords]uint64) *[roaringBitmapWords]uint64) *roaringBitmap {
+	output := newRoaringBitmap()
+
+	// Merge the sorted keys of both bitmaps
+	i, j := 0, 0
+	for i < len(a.keys) || j < len(b.keys) {
+		var key uint16
+		var x, y *[roaringBitmapWords]uint64
+
+		switch {
+		case j == len(b.keys) || (i < len(a.keys) && a.keys[i] < b.keys[j]):
+			key, x = a.keys[i], a.containers[i].words()
+			i++
+		case i == len(a.keys) || b.keys[j] < a.keys[i]:
+			key, y = b.keys[j], b.containers[j].words()
+			j++
+		default:
+			key, x, y = a.keys[i], a.containers[i].words(), b.containers[j].words()
+			i++
+			j++
+		}
+
+		words := op(x, y)
+		if words == nil {
+			continue
+		}
+
+		if c := newBitmapContainer(words); c.card > 0 {
+			output.keys = append(output.keys, key)
+			output.containers = append(output.containers, optimizeContainer(c))
+		}
+	}
+
+	return output
+}
+
+// andRoaring returns the intersection of two roaringBitmaps.
+func andRoaring(a, b *roaringBitmap) *roaringBitmap {
+	return combineRoaring(a, b, func(x, y *[roaringBitmapWords]uint64) *[roaringBitmapWords]uint64 {
+		if x == nil || y == nil {
+			return nil
+		}
+
+		for w := range x {
+			x[w] &= y[w]
+		}
+
+		return x
+	})
+}
+
+// orRoaring returns the union of two roaringBitmaps.
+func orRoaring(a, b *roaringBitmap) *roaringBitmap {
+	return combineRoaring(a, b, func(x, y *[roaringBitmapWords]uint64) *[roaringBitmapWords]uint64 {
+		if x == nil {
+			return y
+		}
+
+		if y != nil {
+			for w := range x {
+				x[w] |= y[w]
+			}
+		}
+
+		return x
+	})
+}
+
+// serialized returns the container in the representation used by the serialized form,
+// which is a run container if it is one and otherwise decided by the cardinality.
+func serialized(c roaringContainer) roaringContainer {
+	if _, ok := c.(*runContainer); ok {
+		return c
+	}
+
+	if c.cardinality() <= roaringArrayMax {
+		return newArrayContainer(c.words())
+	}
+
+	return newBitmapContainer(c.words())
+}
+
+// writeTo writes the roaringBitmap to w in the portable Roaring format.
+func (rb *roaringBitmap) writeTo(w io.Writer) (int64, error) {
+	containers := make([]roaringContainer, len(rb.containers))
+	hasRuns := false
+	for i, c := range rb.containers {
+		containers[i] = serialized(c)
+		if _, ok := containers[i].(*runContainer); ok {
+			hasRuns = true
+		}
+	}
+
+	// Write the cookie and the number of containers, with a bitset of run containers if there are any.
+	// Errors from writing to a bytes.Buffer can be ignored because it always returns nil.
+	header := new(bytes.Buffer)
+	if hasRuns {
+		_ = binary.Write(header, binary.LittleEndian, uint32(roaringCookie|(len(containers)-1)<<16))
+
+		runBitset := make([]byte, (len(containers)+7)/8)
+		for i, c := range containers {
+			if _, ok := c.(*runContainer); ok {
+				runBitset[i/8] |= 1 << (i % 8)
+			}
+		}
+
+		header.Write(runBitset)
+	} else {
+		_ = binary.Write(header, binary.LittleEndian, uint32(roaringCookieNoRuns))
+		_ = binary.Write(header, binary.LittleEndian, uint32(len(containers)))
+	}
+
+	// Write the key and cardinality of every container
+	for i, c := range containers {
+		_ = binary.Write(header, binary.LittleEndian, [2]uint16{rb.keys[i], uint16(c.cardinality() - 1)})
+	}
+
+	// Encode the containers to determine their offsets
+	bodies := make([]*bytes.Buffer, len(containers))
+	for i, c := range containers {
+		bodies[i] = new(bytes.Buffer)
+
+		switch c := c.(type) {
+		case *arrayContainer:
+			_ = binary.Write(bodies[i], binary.LittleEndian, c.values)
+		case *bitmapContainer:
+			_ = binary.Write(bodies[i], binary.LittleEndian, c.bitmap[:])
+		case *runContainer:
+			_ = binary.Write(bodies[i], binary.LittleEndian, uint16(len(c.values)))
+			for _, run := range c.values {
+				_ = binary.Write(bodies[i], binary.LittleEndian, [2]uint16{run.start, run.last - run.start})
+			}
+		}
+	}
+
+	// Write the offset of every container from the start of the bitmap
+	if !hasRuns || len(containers) >= roaringNoOffsetThreshold {
+		offset := uint32(header.Len() + 4*len(containers))
+		for _, body := range bodies {
+			_ = binary.Write(header, binary.LittleEndian, offset)
+			offset += uint32(body.Len())
+		}
+	}
+
+	total, err := header.WriteTo(w)
+	if err != nil {
+		return total, errors.Wrap(err, "failed to write roaring header")
+	}
+
+	for _, body := range bodies {
+		n, err := body.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, errors.Wrap(err, "failed to write roaring container")
+		}
+	}
+
+	return total, nil
+}
+
+// readRoaringBitmap reads a roaringBitmap in the portable Roaring format from r.
+func readRoaringBitmap(r io.Reader) (*roaringBitmap, error) {
+	var cookie uint32
+	if err := binary.Read(r, binary.LittleEndian, &cookie); err != nil {
+		return nil, errors.Wrap(err, "failed to read roaring cookie")
+	}
+
+	// Read the number of containers and the bitset of run containers
+	var count int
+	var runBitset []byte
+	switch {
+	case cookie&0xFFFF == roaringCookie:
+		count = int(cookie>>16) + 1
+		runBitset = make([]byte, (count+7)/8)
+		if _, err := io.ReadFull(r, runBitset); err != nil {
+			return nil, errors.Wrap(err, "failed to read roaring run bitset")
+		}
+	case cookie == roaringCookieNoRuns:
+		var size uint32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, errors.Wrap(err, "failed to read roaring container count")
+		}
+
+		if size > 1<<16 {
+			return nil, errors.Errorf("too many roaring containers: %v", size)
+		}
+
+		count = int(size)
+	default:
+		return nil, errors.Errorf("invalid roaring cookie: %v", cookie)
+	}
+
+	// Read the key and cardinality of every container
+	descriptive := make([]uint16, 2*count)
+	if err := binary.Read(r, binary.LittleEndian, descriptive); err != nil {
+		return nil, errors.Wrap(err, "failed to read roaring container headers")
+	}
+
+	// Skip the offsets, as the containers are read in order
+	if runBitset == nil || count >= roaringNoOffsetThreshold {
+		if _, err := io.CopyN(io.Discard, r, int64(4*count)); err != nil {
+			return nil, errors.Wrap(err, "failed to read roaring offsets")
+		}
+	}
+
+	rb := &roaringBitmap{keys: make([]uint16, count), containers: make([]roaringContainer, count)}
+	for i := 0; i < count; i++ {
+		rb.keys[i] = descriptive[2*i]
+		card := int(descriptive[2*i+1]) + 1
+
+		// Check for keys out of order, which would break lookups
+		if i > 0 && rb.keys[i] <= rb.keys[i-1] {
+			return nil, errors.New("roaring container keys out of order")
+		}
+
+		switch {
+		case runBitset != nil && runBitset[i/8]&(1<<(i%8)) != 0:
+			var runs uint16
+			if err := binary.Read(r, binary.LittleEndian, &runs); err != nil {
+				return nil, errors.Wrap(err, "failed to read roaring run container")
+			}
+
+			pairs := make([]uint16, 2*int(runs))
+			if err := binary.Read(r, binary.LittleEndian, pairs); err != nil {
+				return nil, errors.Wrap(err, "failed to read roaring run container")
+			}
+
+			c := &runContainer{values: make([]roaringRun, runs)}
+			for j := range c.values {
+				c.values[j] = roaringRun{start: pairs[2*j], last: pairs[2*j] + pairs[2*j+1]}
+			}
+
+			rb.containers[i] = c
+		case card <= roaringArrayMax:
+			c := &arrayContainer{values: make([]uint16, card)}
+			if err := binary.Read(r, binary.LittleEndian, c.values); err != nil {
+				return nil, errors.Wrap(err, "failed to read roaring array container")
+			}
+
+			rb.containers[i] = c
+		default:
+			words := new([roaringBitmapWords]uint64)
+			if err := binary.Read(r, binary.LittleEndian, words[:]); err != nil {
+				return nil, errors.Wrap(err, "failed to read roaring bitmap container")
+			}
+
+			rb.containers[i] = newBitmapContainer(words)
+		}
+	}
+
+	return rb, nil
+}
@@ -0,0 +1,214 @@
+package bitvec
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoaringContainers(t *testing.T) {
+	rb := newRoaringBitmap()
+	for i := uint32(0); i < 6000; i++ {
+		rb.add(i * 12)
+	}
+
+	// A chunk switches to a bitmap once it holds more than 4096 values
+	assert.Equal(t, "{0:bitmap(5462) 1:array(538)}", rb.String())
+	assert.True(t, rb.contains(65556))
+	assert.False(t, rb.contains(65557))
+
+	for i := uint32(0); i < 6000; i += 2 {
+		rb.remove(i * 12)
+	}
+
+	assert.Equal(t, "{0:array(2731) 1:array(269)}", rb.String())
+
+	// Consecutive values are turned into runs by optimize
+	rb = newRoaringBitmap()
+	for i := uint32(100); i < 10000; i++ {
+		rb.add(i)
+	}
+
+	rb.optimize()
+	assert.Equal(t, "{0:run(9900)}", rb.String())
+
+	rb.add(10000)
+	rb.add(98)
+	rb.remove(5000)
+	assert.Equal(t, []roaringRun{{98, 98}, {100, 4999}, {5001, 10000}}, rb.containers[0].(*runContainer).values)
+
+	rb.add(99)
+	rb.add(5000)
+	assert.Equal(t, []roaringRun{{98, 10000}}, rb.containers[0].(*runContainer).values)
+}
+
+func TestRoaringVec_WriteTo(t *testing.T) {
+	tests := []struct {
+		indexes []uint64
+		output  []byte
+	}{
+		{
+			[]uint64{1, 2, 3},
+			[]byte{
+				0x3A, 0x30, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x02, 0x00,
+				0x10, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x02, 0x00, 0x03, 0x00,
+			},
+		},
+		{
+			[]uint64{},
+			[]byte{0x3A, 0x30, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, test := range tests {
+		vec, err := NewRoaringVec(100, 1)
+		require.Nil(t, err, "Unexpected Error")
+
+		for _, index := range test.indexes {
+			require.Nil(t, vec.Set(index, 1))
+		}
+
+		buf := new(bytes.Buffer)
+		n, err := vec.WriteTo(buf)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, int64(len(test.output)), n)
+		assert.Equal(t, test.output, buf.Bytes())
+	}
+
+	// A run of 100 values is written as a run container without offsets
+	vec, err := NewRoaringVec(1000, 1)
+	require.Nil(t, err, "Unexpected Error")
+
+	for i := uint64(0); i < 100; i++ {
+		require.Nil(t, vec.Set(i, 1))
+	}
+
+	vec.Optimize()
+
+	buf := new(bytes.Buffer)
+	_, err = vec.WriteTo(buf)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []byte{
+		0x3B, 0x30, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x63, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x63, 0x00,
+	}, buf.Bytes())
+
+	read, err := ReadRoaringVec(bytes.NewReader(buf.Bytes()), 1000, 1)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, vec.ToBitVec().Data, read.ToBitVec().Data)
+
+	_, err = ReadRoaringVec(bytes.NewReader(buf.Bytes()), 50, 1)
+	assert.EqualError(t, err, "index too large for roaring vector count (max: 50)")
+
+	_, err = ReadRoaringVec(bytes.NewReader([]byte{1, 2, 3, 4}), 50, 1)
+	assert.EqualError(t, err, "invalid roaring cookie: 67305985")
+
+	_, err = ReadRoaringVec(bytes.NewReader(buf.Bytes()[:7]), 1000, 1)
+	assert.Error(t, err)
+}
+
+func TestRoaringVec(t *testing.T) {
+	rng := rand.New(rand.NewSource(33))
+
+	dibit := NewDiBit(300000)
+	other := NewDiBit(300000)
+	for n := 0; n < 20000; n++ {
+		require.Nil(t, dibit.Set(uint64(rng.Intn(300000)), uint64(rng.Intn(4))))
+		require.Nil(t, other.Set(uint64(rng.Intn(300000)), uint64(rng.Intn(4))))
+	}
+
+	// A long run of the same state compresses into a run container
+	for i := uint64(70000); i < 130000; i++ {
+		require.Nil(t, dibit.Set(i, 3))
+	}
+
+	vec, err := NewRoaringVecFromDiBit(dibit)
+	require.Nil(t, err, "Unexpected Error")
+
+	output, err := vec.ToDiBit()
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, dibit.Data, output.Data)
+
+	for _, state := range []uint64{0, 1, 2, 3} {
+		expected, err := dibit.Indexes(state)
+		require.Nil(t, err, "Unexpected Error")
+
+		indexes, err := vec.Indexes(state)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, expected, indexes)
+	}
+
+	for i := uint64(0); i < dibit.Count; i += 997 {
+		expected, _ := dibit.State(i)
+		state, err := vec.State(i)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, expected, state)
+	}
+
+	// Serialization round trip
+	buf := new(bytes.Buffer)
+	_, err = vec.WriteTo(buf)
+	require.Nil(t, err, "Unexpected Error")
+
+	read, err := ReadRoaringVec(buf, dibit.Count, 2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, dibit.Data, read.ToBitVec().Data)
+
+	// And and Or match the word-wise operations on the DiBit data
+	otherVec, err := NewRoaringVecFromDiBit(other)
+	require.Nil(t, err, "Unexpected Error")
+
+	and, err := vec.And(otherVec)
+	require.Nil(t, err, "Unexpected Error")
+
+	or, err := vec.Or(otherVec)
+	require.Nil(t, err, "Unexpected Error")
+
+	andData, orData := make([]uint64, len(dibit.Data)), make([]uint64, len(dibit.Data))
+	for w := range dibit.Data {
+		andData[w] = dibit.Data[w] & other.Data[w]
+		orData[w] = dibit.Data[w] | other.Data[w]
+	}
+
+	assert.Equal(t, andData, and.ToBitVec().Data)
+	assert.Equal(t, orData, or.ToBitVec().Data)
+
+	_, err = vec.And(&RoaringVec{Count: 10, Size: 2})
+	assert.EqualError(t, err, "roaring vector shapes do not match ([300000|2] and [10|2])")
+
+	// Unset clears every bit of the state
+	require.Nil(t, vec.Unset(100000))
+	exists, err := vec.Has(100000, 0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+}
+
+func TestRoaringVec_Errors(t *testing.T) {
+	_, err := NewRoaringVec(10, 3)
+	assert.EqualError(t, err, "state size unsupported for roaring vector (max: 2)")
+
+	_, err = NewRoaringVec(1<<32+1, 1)
+	assert.EqualError(t, err, "count too large for roaring vector (max: 4294967296)")
+
+	vec, err := NewRoaringVec(10, 1)
+	require.Nil(t, err, "Unexpected Error")
+
+	assert.EqualError(t, vec.Set(10, 1), "index too large for roaring vector count (max: 10)")
+	assert.EqualError(t, vec.Set(1, 2), "state too large for roaring vector state (max: 1)")
+	assert.EqualError(t, vec.Unset(10), "index too large for roaring vector count (max: 10)")
+
+	_, err = vec.State(10)
+	assert.EqualError(t, err, "index too large for roaring vector count (max: 10)")
+
+	_, err = vec.Indexes(2)
+	assert.EqualError(t, err, "state too large for roaring vector state (max: 1)")
+
+	_, err = vec.ToDiBit()
+	assert.EqualError(t, err, "roaring vector size does not match dibit size (size: 1)")
+}
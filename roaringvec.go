@@ -0,0 +1,397 @@
+package bitvec
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MAXROARINGSIZE is the maximum allowed Size for a RoaringVec state.
+const MAXROARINGSIZE = 2
+
+// MAXROARINGCOUNT is the maximum allowed Count for a RoaringVec, as its indexes are 32-bit values.
+const MAXROARINGCOUNT = 1 << 32
+
+// RoaringVec is a struct that maintains some number of responses of at most MAXROARINGSIZE
+// bits as compressed Roaring bitmaps. It supports the same operations as BitVec and DiBit.
+//
+// Every bit of the state is stored in its own bitmap, which holds the indexes of the responses
+// with that bit set. Each bitmap splits the indexes into chunks of 65536 and stores every chunk
+// in an array, bitmap or run container, whichever is the most compact for it.
+type RoaringVec struct {
+	// mu is the thread safety mutex
+	mu sync.Mutex
+
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+
+	// planes holds a bitmap of the responses with each bit of their state set
+	planes []*roaringBitmap
+}
+
+// NewRoaringVec is a constructor function for RoaringVec.
+// Returns an error if Size is greater than MAXROARINGSIZE or if Count is greater than MAXROARINGCOUNT.
+func NewRoaringVec(count, size uint64) (*RoaringVec, error) {
+	// Check if given Size is supported
+	if size == 0 || size > MAXROARINGSIZE {
+		return nil, errors.Errorf("state size unsupported for roaring vector (max: %v)", MAXROARINGSIZE)
+	}
+
+	// Check if given Count is supported
+	if count > MAXROARINGCOUNT {
+		return nil, errors.Errorf("count too large for roaring vector (max: %v)", uint64(MAXROARINGCOUNT))
+	}
+
+	vec := &RoaringVec{mu: sync.Mutex{}, Count: count, Size: size, planes: make([]*roaringBitmap, size)}
+	for b := range vec.planes {
+		vec.planes[b] = newRoaringBitmap()
+	}
+
+	return vec, nil
+}
+
+// newRoaringVecFromData is a constructor function for RoaringVec from count slots of size bits packed into data.
+func newRoaringVecFromData(data []uint64, layout Layout, count, size uint64) (*RoaringVec, error) {
+	vec, err := NewRoaringVec(count, size)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := slotReader{data: data, layout: layout, size: size}
+	for i := uint64(0); i < count; i++ {
+		for state := reader.read(); state != 0; state &= state - 1 {
+			vec.planes[bits.TrailingZeros64(state)].add(uint32(i))
+		}
+	}
+
+	vec.Optimize()
+	return vec, nil
+}
+
+// NewRoaringVecFromBitVec is a constructor function for RoaringVec with the responses of a BitVec.
+// Returns an error if the Size or Count of the BitVec are not supported by a RoaringVec.
+func NewRoaringVecFromBitVec(vec *BitVec) (*RoaringVec, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return newRoaringVecFromData(vec.Data, vec.Layout, vec.Count, vec.Size)
+}
+
+// NewRoaringVecFromDiBit is a constructor function for RoaringVec with the responses of a DiBit.
+// Returns an error if the Count of the DiBit is not supported by a RoaringVec.
+func NewRoaringVecFromDiBit(vec *DiBit) (*RoaringVec, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return newRoaringVecFromData(vec.Data, vec.Layout, vec.Count, DIBITSIZE)
+}
+
+// String implements the Stringer interface for RoaringVec
+func (vec *RoaringVec) String() string {
+	return fmt.Sprintf("[%v|%v] %v", vec.Count, vec.Size, vec.planes)
+}
+
+// MaxState is a method of RoaringVec that returns the maximum value for a state for that RoaringVec.
+// It is calculated as 2^StateBits-1.
+func (vec *RoaringVec) MaxState() uint64 {
+	return 1<<vec.Size - 1
+}
+
+// Optimize is a method of RoaringVec that switches every container to the representation that
+// uses the least memory. Containers switch between arrays and bitmaps as they grow and shrink,
+// but are only turned into run containers by Optimize, And and Or.
+func (vec *RoaringVec) Optimize() {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	for _, plane := range vec.planes {
+		plane.optimize()
+	}
+}
+
+// pack writes the states of all responses into data in the given layout.
+func (vec *RoaringVec) pack(data []uint64, layout Layout) {
+	for b, plane := range vec.planes {
+		for i, key := range plane.keys {
+			words := plane.containers[i].words()
+			for w, word := range words {
+				for ; word != 0; word &= word - 1 {
+					index := uint64(key)<<16 | uint64(w*64+bits.TrailingZeros64(word))
+					pos := index * vec.Size
+					writeBits(data, layout, pos, vec.Size, readBits(data, layout, pos, vec.Size)|1<<b)
+				}
+			}
+		}
+	}
+}
+
+// ToBitVec is a method of RoaringVec that returns a BitVec with the same responses.
+func (vec *RoaringVec) ToBitVec() *BitVec {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Error can be ignored because the Size has already been checked
+	output, _ := NewBitVec(vec.Count, vec.Size)
+	vec.pack(output.Data, output.Layout)
+
+	return output
+}
+
+// ToDiBit is a method of RoaringVec that returns a DiBit with the same responses.
+// Returns an error if the Size is not DIBITSIZE.
+func (vec *RoaringVec) ToDiBit() (*DiBit, error) {
+	// Check for a Size other than a DiBit
+	if vec.Size != DIBITSIZE {
+		return nil, errors.Errorf("roaring vector size does not match dibit size (size: %v)", vec.Size)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	output := NewDiBit(vec.Count)
+	vec.pack(output.Data, output.Layout)
+
+	return output, nil
+}
+
+// Set is a method of RoaringVec that sets a given state at given index.
+// Like BitVec.Set, the state is merged into the existing state of the response.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the RoaringVec.
+func (vec *RoaringVec) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for roaring vector count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for RoaringVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for roaring vector state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	for b, plane := range vec.planes {
+		if state&(1<<b) != 0 {
+			plane.add(uint32(index))
+		}
+	}
+
+	return nil
+}
+
+// Unset is a method of RoaringVec that unsets the state for a given index.
+// Returns an error index is out of bounds.
+func (vec *RoaringVec) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for roaring vector count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	for _, plane := range vec.planes {
+		plane.remove(uint32(index))
+	}
+
+	return nil
+}
+
+// read returns the state of the response at the given index.
+func (vec *RoaringVec) read(index uint64) uint64 {
+	var state uint64
+	for b, plane := range vec.planes {
+		if plane.contains(uint32(index)) {
+			state |= 1 << b
+		}
+	}
+
+	return state
+}
+
+// Has is a method of RoaringVec that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the RoaringVec.
+func (vec *RoaringVec) Has(index, state uint64) (bool, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return false, errors.Errorf("index too large for roaring vector count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for RoaringVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for roaring vector state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.read(index) == state, nil
+}
+
+// State is a method of RoaringVec that returns the state at a given index.
+// Returns an error if the index is out of bounds.
+func (vec *RoaringVec) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for roaring vector count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.read(index), nil
+}
+
+// Indexes is a method of RoaringVec that returns the slice of indexes matching the given state.
+// Returns an error if state value exceeds the maximum for the RoaringVec.
+func (vec *RoaringVec) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for RoaringVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for roaring vector state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	indexes := make([]uint64, 0)
+	for key := uint64(0); key<<16 < vec.Count; key++ {
+		// Combine the chunk of every plane into the responses matching the state
+		var matches [roaringBitmapWords]uint64
+		for w := range matches {
+			matches[w] = 1<<64 - 1
+		}
+
+		for b, plane := range vec.planes {
+			words := plane.chunk(uint16(key))
+			for w := range matches {
+				var word uint64
+				if words != nil {
+					word = words[w]
+				}
+
+				if state&(1<<b) == 0 {
+					word = ^word
+				}
+
+				matches[w] &= word
+			}
+		}
+
+		// Append the matching indexes before the Count
+		for w, word := range matches {
+			for ; word != 0; word &= word - 1 {
+				index := key<<16 | uint64(w*64+bits.TrailingZeros64(word))
+				if index >= vec.Count {
+					return indexes, nil
+				}
+
+				indexes = append(indexes, index)
+			}
+		}
+	}
+
+	return indexes, nil
+}
+
+// combine returns a new RoaringVec with the planes of vec and other combined by op.
+func (vec *RoaringVec) combine(other *RoaringVec, op func(a, b *roaringBitmap) *roaringBitmap) (*RoaringVec, error) {
+	// Check that both vectors have the same shape
+	if vec.Count != other.Count || vec.Size != other.Size {
+		return nil, errors.Errorf("roaring vector shapes do not match ([%v|%v] and [%v|%v])", vec.Count, vec.Size, other.Count, other.Size)
+	}
+
+	// Acquire both mutexes
+	unlock := lockPair(&vec.mu, &other.mu)
+	defer unlock()
+
+	output := &RoaringVec{mu: sync.Mutex{}, Count: vec.Count, Size: vec.Size, planes: make([]*roaringBitmap, vec.Size)}
+	for b := range output.planes {
+		output.planes[b] = op(vec.planes[b], other.planes[b])
+	}
+
+	return output, nil
+}
+
+// And is a method of RoaringVec that returns a new RoaringVec with the bitwise AND of the states of both vectors.
+// Returns an error if the Count or Size of the vectors differ.
+func (vec *RoaringVec) And(other *RoaringVec) (*RoaringVec, error) {
+	return vec.combine(other, andRoaring)
+}
+
+// Or is a method of RoaringVec that returns a new RoaringVec with the bitwise OR of the states of both vectors.
+// Returns an error if the Count or Size of the vectors differ.
+func (vec *RoaringVec) Or(other *RoaringVec) (*RoaringVec, error) {
+	return vec.combine(other, orRoaring)
+}
+
+// WriteTo is a method of RoaringVec that writes its bitmaps to w in the portable Roaring format.
+// The bitmap of the lowest bit of the states comes first, so for a Size of 1 the output is
+// a single Roaring bitmap of the indexes with the state 1 that other tools can read directly.
+// The Count and Size are not written and must be given to ReadRoaringVec.
+func (vec *RoaringVec) WriteTo(w io.Writer) (int64, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	var total int64
+	for _, plane := range vec.planes {
+		n, err := plane.writeTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// ReadRoaringVec is a constructor function for RoaringVec that reads the bitmaps written by WriteTo from r.
+// Returns an error if the Size or Count are not supported, if the bitmaps are malformed or hold indexes beyond the Count.
+func ReadRoaringVec(r io.Reader, count, size uint64) (*RoaringVec, error) {
+	vec, err := NewRoaringVec(count, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for b := range vec.planes {
+		plane, err := readRoaringBitmap(r)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check for indexes beyond the Count in the last container
+		if n := len(plane.keys); n > 0 {
+			words := plane.containers[n-1].words()
+			for w := roaringBitmapWords - 1; w >= 0; w-- {
+				if words[w] != 0 {
+					last := uint64(plane.keys[n-1])<<16 | uint64(w*64+63-bits.LeadingZeros64(words[w]))
+					if last >= count {
+						return nil, errors.Errorf("index too large for roaring vector count (max: %v)", count)
+					}
+
+					break
+				}
+			}
+		}
+
+		vec.planes[b] = plane
+	}
+
+	return vec, nil
+}
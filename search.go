@@ -0,0 +1,169 @@
+package bitvec
+
+import (
+	"math"
+)
+
+// nextIndex returns the first index at or after from of count slots of size bits in the data with the given state.
+// Words without a matching slot are skipped with a single comparison if the slots are aligned.
+func nextIndex(data []uint64, layout Layout, count, size, from, state uint64) (uint64, bool) {
+	if from >= count {
+		return 0, false
+	}
+
+	if !aligned(size) {
+		for i := from; i < count; i++ {
+			if readBits(data, layout, i*size, size) == state {
+				return i, true
+			}
+		}
+
+		return 0, false
+	}
+
+	used, perWord := count*size, 64/size
+	pos := from * size
+
+	for w := int(pos / 64); w < len(data); w++ {
+		mask := wordMatches(data, layout, size, used, w, state)
+
+		// Clear the fields of the slots before from in its word
+		if w == int(pos/64) {
+			mask &^= leadingBits(layout, pos%64)
+		}
+
+		if mask != 0 {
+			bit, _ := firstField(layout, mask)
+			return uint64(w)*perWord + fieldSlot(layout, size, bit), true
+		}
+	}
+
+	return 0, false
+}
+
+// prevIndex returns the last index at or before from of count slots of size bits in the data with the given state.
+// Words without a matching slot are skipped with a single comparison if the slots are aligned.
+func prevIndex(data []uint64, layout Layout, count, size, from, state uint64) (uint64, bool) {
+	if count == 0 {
+		return 0, false
+	}
+
+	if from >= count {
+		from = count - 1
+	}
+
+	if !aligned(size) {
+		for i := from + 1; i > 0; i-- {
+			if readBits(data, layout, (i-1)*size, size) == state {
+				return i - 1, true
+			}
+		}
+
+		return 0, false
+	}
+
+	used, perWord := count*size, 64/size
+	pos := from * size
+
+	for w := int(pos / 64); w >= 0; w-- {
+		mask := wordMatches(data, layout, size, used, w, state)
+
+		// Clear the fields of the slots after from in its word
+		if w == int(pos/64) {
+			mask &= leadingBits(layout, pos%64+size)
+		}
+
+		if mask != 0 {
+			bit, _ := lastField(layout, mask)
+			return uint64(w)*perWord + fieldSlot(layout, size, bit), true
+		}
+	}
+
+	return 0, false
+}
+
+// NextIndex is a method of BitVec that returns the first index at or after from with the given state.
+// Returns false if there is no such index, including if from is out of bounds or if the state value
+// exceeds the maximum for the BitVec.
+func (vec *BitVec) NextIndex(from, state uint64) (uint64, bool) {
+	if state > vec.MaxState() {
+		return 0, false
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return nextIndex(vec.Data, vec.Layout, vec.Count, vec.Size, from, state)
+}
+
+// PrevIndex is a method of BitVec that returns the last index at or before from with the given state.
+// A from beyond the Count searches from the last index. Returns false if there is no such index,
+// including if the state value exceeds the maximum for the BitVec.
+func (vec *BitVec) PrevIndex(from, state uint64) (uint64, bool) {
+	if state > vec.MaxState() {
+		return 0, false
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return prevIndex(vec.Data, vec.Layout, vec.Count, vec.Size, from, state)
+}
+
+// FirstIndex is a method of BitVec that returns the first index with the given state.
+// Returns false if there is no such index.
+func (vec *BitVec) FirstIndex(state uint64) (uint64, bool) {
+	return vec.NextIndex(0, state)
+}
+
+// LastIndex is a method of BitVec that returns the last index with the given state.
+// Returns false if there is no such index.
+func (vec *BitVec) LastIndex(state uint64) (uint64, bool) {
+	// The largest index is clamped to the last index under the mutex
+	return vec.PrevIndex(math.MaxUint64, state)
+}
+
+// NextIndex is a method of DiBit that returns the first index at or after from with the given state.
+// Returns false if there is no such index, including if from is out of bounds or if the state value
+// exceeds the maximum for the DiBit.
+func (vec *DiBit) NextIndex(from, state uint64) (uint64, bool) {
+	if state > vec.MaxState() {
+		return 0, false
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return nextIndex(vec.Data, vec.Layout, vec.Count, DIBITSIZE, from, state)
+}
+
+// PrevIndex is a method of DiBit that returns the last index at or before from with the given state.
+// A from beyond the Count searches from the last index. Returns false if there is no such index,
+// including if the state value exceeds the maximum for the DiBit.
+func (vec *DiBit) PrevIndex(from, state uint64) (uint64, bool) {
+	if state > vec.MaxState() {
+		return 0, false
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return prevIndex(vec.Data, vec.Layout, vec.Count, DIBITSIZE, from, state)
+}
+
+// FirstIndex is a method of DiBit that returns the first index with the given state.
+// Returns false if there is no such index.
+func (vec *DiBit) FirstIndex(state uint64) (uint64, bool) {
+	return vec.NextIndex(0, state)
+}
+
+// LastIndex is a method of DiBit that returns the last index with the given state.
+// Returns false if there is no such index.
+func (vec *DiBit) LastIndex(state uint64) (uint64, bool) {
+	// The largest index is clamped to the last index under the mutex
+	return vec.PrevIndex(math.MaxUint64, state)
+}
@@ -0,0 +1,121 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_NextIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(45))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 3, 4, 8, 64} {
+			vec, err := NewBitVecWithLayout(500, size, layout)
+			require.Nil(t, err, "Unexpected Error")
+
+			// Sparse non-zero states, so that whole words are skipped
+			for n := 0; n < 20; n++ {
+				require.Nil(t, vec.Set(uint64(rng.Intn(500)), 1))
+			}
+
+			states := vec.ToStates()
+			for _, state := range []uint64{0, 1} {
+				for from := uint64(0); from < 510; from++ {
+					next, nextOk := uint64(0), false
+					for i := from; i < 500; i++ {
+						if states[i] == state {
+							next, nextOk = i, true
+							break
+						}
+					}
+
+					index, ok := vec.NextIndex(from, state)
+					assert.Equal(t, nextOk, ok)
+					assert.Equal(t, next, index)
+
+					prev, prevOk := uint64(0), false
+					for i := int(from); i >= 0; i-- {
+						if i < 500 && states[i] == state {
+							prev, prevOk = uint64(i), true
+							break
+						}
+					}
+
+					index, ok = vec.PrevIndex(from, state)
+					assert.Equal(t, prevOk, ok)
+					assert.Equal(t, prev, index)
+				}
+			}
+
+			indexes, _ := vec.Indexes(1)
+			first, ok := vec.FirstIndex(1)
+			assert.True(t, ok)
+			assert.Equal(t, indexes[0], first)
+
+			last, ok := vec.LastIndex(1)
+			assert.True(t, ok)
+			assert.Equal(t, indexes[len(indexes)-1], last)
+		}
+	}
+
+	// The padding of the last word never matches
+	vec, _ := NewBitVec(10, 4)
+	_, ok := vec.NextIndex(0, 1)
+	assert.False(t, ok)
+
+	index, ok := vec.LastIndex(0)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(9), index)
+
+	_, ok = vec.NextIndex(0, 16)
+	assert.False(t, ok)
+
+	_, ok = vec.PrevIndex(5, 16)
+	assert.False(t, ok)
+
+	empty, _ := NewBitVec(0, 2)
+	_, ok = empty.LastIndex(0)
+	assert.False(t, ok)
+}
+
+func TestDiBit_NextIndex(t *testing.T) {
+	vec := NewDiBit(100)
+	require.Nil(t, vec.Set(31, 2))
+	require.Nil(t, vec.Set(32, 2))
+	require.Nil(t, vec.Set(90, 2))
+
+	index, ok := vec.NextIndex(0, 2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(31), index)
+
+	index, ok = vec.NextIndex(33, 2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(90), index)
+
+	index, ok = vec.PrevIndex(89, 2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(32), index)
+
+	index, ok = vec.FirstIndex(0)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), index)
+
+	index, ok = vec.LastIndex(2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(90), index)
+
+	_, ok = vec.NextIndex(91, 2)
+	assert.False(t, ok)
+
+	_, ok = vec.PrevIndex(30, 2)
+	assert.False(t, ok)
+
+	_, ok = vec.FirstIndex(3)
+	assert.False(t, ok)
+
+	_, ok = vec.FirstIndex(4)
+	assert.False(t, ok)
+}
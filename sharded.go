@@ -0,0 +1,267 @@
+package bitvec
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ShardedBitVec is a struct that maintains some number of responses like BitVec,
+// with the index space partitioned into shards that each have their own mutex and Data,
+// so that writes to different shards do not contend. Every shard holds a whole number
+// of words, so no response straddles two shards and the Data of the shards in order is
+// the Data of the equivalent BitVec.
+type ShardedBitVec struct {
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+	// Layout is the order in which responses are packed into Data
+	Layout Layout
+
+	// shardCount is the number of responses of every shard but the last
+	shardCount uint64
+	// shards are the BitVecs holding consecutive ranges of responses
+	shards []*BitVec
+}
+
+// NewShardedBitVec is a constructor function for ShardedBitVec with the default MSBFirst layout,
+// partitioning the responses into at most the given number of shards.
+// Returns an error if Size is greater than MAXVECSIZE or if the number of shards is zero.
+func NewShardedBitVec(count, size, shards uint64) (*ShardedBitVec, error) {
+	return NewShardedBitVecWithLayout(count, size, shards, MSBFirst)
+}
+
+// NewShardedBitVecWithLayout is a constructor function for ShardedBitVec with the given Layout,
+// partitioning the responses into at most the given number of shards. Shards are rounded up to a
+// whole number of words, so fewer shards are created if the Count is too small to fill them.
+// Returns an error if Size is greater than MAXVECSIZE, if the number of shards is zero or if the Layout is unknown.
+func NewShardedBitVecWithLayout(count, size, shards uint64, layout Layout) (*ShardedBitVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	if shards == 0 {
+		return nil, errors.New("shard count must be positive")
+	}
+
+	// The responses of a shard must fill a whole number of words
+	align := uint64(64)
+	if size > 0 {
+		align = 64 / gcd(size, 64)
+	}
+
+	shardCount := (count + shards - 1) / shards
+	shardCount = (shardCount + align - 1) / align * align
+	if shardCount == 0 {
+		shardCount = align
+	}
+
+	vec := &ShardedBitVec{Count: count, Size: size, Layout: layout, shardCount: shardCount}
+	for start := uint64(0); start < count; start += shardCount {
+		n := shardCount
+		if count-start < n {
+			n = count - start
+		}
+
+		// Error can be ignored because Size and Layout have already been checked
+		shard, _ := NewBitVecWithLayout(n, size, layout)
+		vec.shards = append(vec.shards, shard)
+	}
+
+	return vec, nil
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// String implements the Stringer interface for ShardedBitVec
+func (vec *ShardedBitVec) String() string {
+	return fmt.Sprintf("[%v|%v] %v shards of %v", vec.Count, vec.Size, len(vec.shards), vec.shardCount)
+}
+
+// Shards is a method of ShardedBitVec that returns the number of shards.
+func (vec *ShardedBitVec) Shards() int {
+	return len(vec.shards)
+}
+
+// MaxState is a method of ShardedBitVec that returns the maximum value for a state for that ShardedBitVec.
+// It is calculated as 2^StateBits-1.
+func (vec *ShardedBitVec) MaxState() uint64 {
+	return 1<<vec.Size - 1
+}
+
+// locate returns the shard holding the response at the given index and the index within the shard.
+func (vec *ShardedBitVec) locate(index uint64) (*BitVec, uint64) {
+	return vec.shards[index/vec.shardCount], index % vec.shardCount
+}
+
+// lockAll acquires the mutexes of all shards in order, for a consistent snapshot across shards.
+func (vec *ShardedBitVec) lockAll() {
+	for _, shard := range vec.shards {
+		shard.mu.Lock()
+	}
+}
+
+// unlockAll releases the mutexes of all shards acquired with lockAll.
+func (vec *ShardedBitVec) unlockAll() {
+	for i := len(vec.shards) - 1; i >= 0; i-- {
+		vec.shards[i].mu.Unlock()
+	}
+}
+
+// Set is a method of ShardedBitVec that sets a given state at given index.
+// Only the shard holding the index is locked.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the ShardedBitVec.
+func (vec *ShardedBitVec) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for sharded bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for ShardedBitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for sharded bitvec state (max: %v)", vec.MaxState())
+	}
+
+	shard, index := vec.locate(index)
+	return shard.Set(index, state)
+}
+
+// Unset is a method of ShardedBitVec that unsets the state for a given index.
+// Only the shard holding the index is locked. Returns an error index is out of bounds.
+func (vec *ShardedBitVec) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for sharded bitvec count (max: %v)", vec.Count)
+	}
+
+	shard, index := vec.locate(index)
+	return shard.Unset(index)
+}
+
+// Has is a method of ShardedBitVec that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the ShardedBitVec.
+func (vec *ShardedBitVec) Has(index, state uint64) (bool, error) {
+	// Check for state value too large for ShardedBitVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for sharded bitvec state (max: %v)", vec.MaxState())
+	}
+
+	current, err := vec.State(index)
+	if err != nil {
+		return false, err
+	}
+
+	return current == state, nil
+}
+
+// State is a method of ShardedBitVec that returns the state at a given index.
+// Only the shard holding the index is locked. Returns an error if the index is out of bounds.
+func (vec *ShardedBitVec) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for sharded bitvec count (max: %v)", vec.Count)
+	}
+
+	shard, index := vec.locate(index)
+
+	// Acquire the mutex of the shard
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return readBits(shard.Data, shard.Layout, index*shard.Size, shard.Size), nil
+}
+
+// Indexes is a method of ShardedBitVec that returns the slice of indexes matching the given state,
+// from a consistent snapshot across all shards.
+// Returns an error if state value exceeds the maximum for the ShardedBitVec.
+func (vec *ShardedBitVec) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for ShardedBitVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for sharded bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutexes of all shards
+	vec.lockAll()
+	defer vec.unlockAll()
+
+	indexes := make([]uint64, 0)
+	for s, shard := range vec.shards {
+		reader := slotReader{data: shard.Data, layout: shard.Layout, size: shard.Size}
+		for i := uint64(0); i < shard.Count; i++ {
+			if reader.read() == state {
+				indexes = append(indexes, uint64(s)*vec.shardCount+i)
+			}
+		}
+	}
+
+	return indexes, nil
+}
+
+// ToBitVec is a method of ShardedBitVec that returns a BitVec holding
+// a consistent snapshot of the states of all shards.
+func (vec *ShardedBitVec) ToBitVec() *BitVec {
+	// Acquire the mutexes of all shards
+	vec.lockAll()
+	defer vec.unlockAll()
+
+	return vec.snapshot()
+}
+
+// snapshot returns a BitVec with the Data of all shards while they are locked.
+func (vec *ShardedBitVec) snapshot() *BitVec {
+	// Error can be ignored because Size and Layout have already been checked
+	output, _ := NewBitVecWithLayout(vec.Count, vec.Size, vec.Layout)
+
+	// Every shard holds whole words, so the Data of the shards is concatenated
+	offset := 0
+	for _, shard := range vec.shards {
+		offset += copy(output.Data[offset:], shard.Data)
+	}
+
+	return output
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface for ShardedBitVec,
+// in the binary serialization format of the equivalent BitVec.
+func (vec *ShardedBitVec) MarshalBinary() ([]byte, error) {
+	return vec.ToBitVec().MarshalBinary()
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface for ShardedBitVec.
+// The data must hold a BitVec with the Count, Size and Layout of the ShardedBitVec.
+func (vec *ShardedBitVec) UnmarshalBinary(data []byte) error {
+	input := new(BitVec)
+	if err := input.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	if input.Count != vec.Count || input.Size != vec.Size || input.Layout != vec.Layout {
+		return errors.Errorf("binary data does not match sharded bitvec ([%v|%v] %v)", input.Count, input.Size, input.Layout)
+	}
+
+	// Acquire the mutexes of all shards
+	vec.lockAll()
+	defer vec.unlockAll()
+
+	offset := 0
+	for _, shard := range vec.shards {
+		offset += copy(shard.Data, input.Data[offset:])
+		shard.modified()
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package bitvec
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardedBitVec(t *testing.T) {
+	tests := []struct {
+		count, size, shards uint64
+		output              string
+		err                 string
+	}{
+		{1000, 5, 4, "[1000|5] 4 shards of 256", ""},
+		{100, 3, 8, "[100|3] 2 shards of 64", ""},
+		{1000, 2, 3, "[1000|2] 3 shards of 352", ""},
+		{1000, 64, 10, "[1000|64] 10 shards of 100", ""},
+		{0, 2, 4, "[0|2] 0 shards of 32", ""},
+		{10, 70, 4, "", "state size greater 64 not allowed"},
+		{10, 2, 0, "", "shard count must be positive"},
+	}
+
+	for _, test := range tests {
+		vec, err := NewShardedBitVec(test.count, test.size, test.shards)
+
+		if test.err == "" {
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, test.output, vec.String())
+
+			// No response straddles two shards
+			for s := 0; s+1 < len(vec.shards); s++ {
+				assert.Zero(t, vec.shards[s].Count*vec.shards[s].Size%64)
+			}
+		} else {
+			assert.EqualError(t, err, test.err)
+			assert.Nil(t, vec)
+		}
+	}
+
+	_, err := NewShardedBitVecWithLayout(10, 2, 4, Layout(5))
+	assert.EqualError(t, err, "unknown bit layout: 5")
+}
+
+func TestShardedBitVec(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		vec, err := NewBitVecWithLayout(3000, 5, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		sharded, err := NewShardedBitVecWithLayout(3000, 5, 7, layout)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, 7, sharded.Shards())
+
+		for n := 0; n < 3000; n++ {
+			index, state := uint64(rng.Intn(3000)), uint64(rng.Intn(32))
+			if rng.Intn(4) == 0 {
+				require.Nil(t, vec.Unset(index))
+				require.Nil(t, sharded.Unset(index))
+			} else {
+				require.Nil(t, vec.Set(index, state))
+				require.Nil(t, sharded.Set(index, state))
+			}
+		}
+
+		assert.Equal(t, vec.Data, sharded.ToBitVec().Data)
+
+		for i := uint64(0); i < vec.Count; i += 7 {
+			expected, _ := vec.State(i)
+			exists, err := sharded.Has(i, expected)
+			require.Nil(t, err, "Unexpected Error")
+			assert.True(t, exists)
+		}
+
+		for state := uint64(0); state < 32; state += 5 {
+			expected, _ := vec.Indexes(state)
+			indexes, err := sharded.Indexes(state)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, expected, indexes)
+		}
+
+		// Serialization uses the format of the equivalent BitVec
+		data, err := sharded.MarshalBinary()
+		require.Nil(t, err, "Unexpected Error")
+
+		expected, _ := vec.MarshalBinary()
+		assert.Equal(t, expected, data)
+
+		output, _ := NewShardedBitVecWithLayout(3000, 5, 3, layout)
+		require.Nil(t, output.UnmarshalBinary(data))
+		assert.Equal(t, vec.Data, output.ToBitVec().Data)
+
+		other, _ := NewShardedBitVec(2000, 5, 3)
+		assert.EqualError(t, other.UnmarshalBinary(data),
+			"binary data does not match sharded bitvec ([3000|5] "+layout.String()+")")
+	}
+}
+
+func TestShardedBitVec_Concurrent(t *testing.T) {
+	sharded, err := NewShardedBitVec(4096, 2, 8)
+	require.Nil(t, err, "Unexpected Error")
+
+	// Every goroutine writes its own interleaved indexes across all shards
+	var wg sync.WaitGroup
+	for g := uint64(0); g < 8; g++ {
+		wg.Add(1)
+		go func(g uint64) {
+			defer wg.Done()
+			for i := g; i < sharded.Count; i += 8 {
+				_ = sharded.Set(i, g%3+1)
+			}
+		}(g)
+	}
+
+	for n := 0; n < 10; n++ {
+		_, _ = sharded.Indexes(1)
+	}
+
+	wg.Wait()
+
+	for i := uint64(0); i < sharded.Count; i++ {
+		state, err := sharded.State(i)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, i%8%3+1, state)
+	}
+}
+
+func TestShardedBitVec_Errors(t *testing.T) {
+	vec, err := NewShardedBitVec(10, 2, 2)
+	require.Nil(t, err, "Unexpected Error")
+
+	assert.EqualError(t, vec.Set(10, 1), "index too large for sharded bitvec count (max: 10)")
+	assert.EqualError(t, vec.Set(1, 4), "state too large for sharded bitvec state (max: 3)")
+	assert.EqualError(t, vec.Unset(10), "index too large for sharded bitvec count (max: 10)")
+
+	_, err = vec.Has(10, 1)
+	assert.EqualError(t, err, "index too large for sharded bitvec count (max: 10)")
+
+	_, err = vec.Has(1, 4)
+	assert.EqualError(t, err, "state too large for sharded bitvec state (max: 3)")
+
+	_, err = vec.State(10)
+	assert.EqualError(t, err, "index too large for sharded bitvec count (max: 10)")
+
+	_, err = vec.Indexes(4)
+	assert.EqualError(t, err, "state too large for sharded bitvec state (max: 3)")
+}
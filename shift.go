@@ -0,0 +1,235 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// clearBits clears the n bits starting at bit position pos of the data, 64 bits at a time.
+func clearBits(data []uint64, layout Layout, pos, n uint64) {
+	for done := uint64(0); done < n; done += 64 {
+		chunk := n - done
+		if chunk > 64 {
+			chunk = 64
+		}
+
+		writeBits(data, layout, pos+done, chunk, 0)
+	}
+}
+
+// insertSlot returns the data with the state inserted as a new slot at the index of count slots of size bits.
+// The later slots are moved up by one slot and the data grows by a word if the slots no longer fit.
+func insertSlot(data []uint64, layout Layout, count, size, index, state uint64) []uint64 {
+	if words := ((count+1)*size + 63) / 64; uint64(len(data)) < words {
+		data = append(data, 0)
+	}
+
+	copyBits(data, (index+1)*size, data, index*size, (count-index)*size, layout)
+	writeBits(data, layout, index*size, size, state)
+
+	return data
+}
+
+// deleteSlot returns the data with the slot at the index of count slots of size bits removed.
+// The later slots are moved down by one slot, the freed bits are cleared and unused words are dropped.
+func deleteSlot(data []uint64, layout Layout, count, size, index uint64) []uint64 {
+	copyBits(data, index*size, data, (index+1)*size, (count-index-1)*size, layout)
+	clearBits(data, layout, (count-1)*size, size)
+
+	return data[:((count-1)*size+63)/64]
+}
+
+// shiftSlots moves count slots of size bits by n slots toward index 0 if down is set and toward the
+// last index otherwise. Slots moved beyond either end are discarded and vacated slots are cleared.
+func shiftSlots(data []uint64, layout Layout, count, size, n uint64, down bool) {
+	if n > count {
+		n = count
+	}
+
+	kept := (count - n) * size
+	if down {
+		copyBits(data, 0, data, n*size, kept, layout)
+		clearBits(data, layout, kept, n*size)
+	} else {
+		copyBits(data, n*size, data, 0, kept, layout)
+		clearBits(data, layout, 0, n*size)
+	}
+}
+
+// rotateSlots moves count slots of size bits by n slots toward the last index,
+// with the slots moved beyond the last index wrapping around to index 0.
+func rotateSlots(data []uint64, layout Layout, count, size, n uint64) {
+	if count == 0 {
+		return
+	}
+
+	n %= count
+	if n == 0 {
+		return
+	}
+
+	// Hold the slots that wrap around while the others are moved up
+	wrapped := make([]uint64, (n*size+63)/64)
+	copyBits(wrapped, 0, data, (count-n)*size, n*size, layout)
+	copyBits(data, n*size, data, 0, (count-n)*size, layout)
+	copyBits(data, 0, wrapped, 0, n*size, layout)
+}
+
+// InsertAt is a method of BitVec that inserts a response with the given state at the given index,
+// moving the responses from the index onwards up by one and increasing the Count by one.
+// Returns an error if the index is greater than the Count or if the state value exceeds the maximum for the BitVec.
+// All other methods check their indexes against the Count under the mutex, so they may run concurrently.
+func (vec *BitVec) InsertAt(index, state uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index, where the Count appends a response
+	if index > vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	vec.Data = insertSlot(vec.Data, vec.Layout, vec.Count, vec.Size, index, state)
+	vec.Count++
+	vec.modified()
+
+	return nil
+}
+
+// DeleteAt is a method of BitVec that removes the response at the given index,
+// moving the later responses down by one and decreasing the Count by one.
+// Returns an error if the index is out of bounds.
+func (vec *BitVec) DeleteAt(index uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	vec.Data = deleteSlot(vec.Data, vec.Layout, vec.Count, vec.Size, index)
+	vec.Count--
+	vec.modified()
+
+	return nil
+}
+
+// ShiftLeft is a method of BitVec that moves all responses n indexes toward index 0.
+// The first n responses are discarded and the last n responses are unset.
+func (vec *BitVec) ShiftLeft(n uint64) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	shiftSlots(vec.Data, vec.Layout, vec.Count, vec.Size, n, true)
+	vec.modified()
+}
+
+// ShiftRight is a method of BitVec that moves all responses n indexes toward the last index.
+// The last n responses are discarded and the first n responses are unset.
+func (vec *BitVec) ShiftRight(n uint64) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	shiftSlots(vec.Data, vec.Layout, vec.Count, vec.Size, n, false)
+	vec.modified()
+}
+
+// Rotate is a method of BitVec that moves all responses n indexes toward the last index,
+// with the last n responses wrapping around to the first indexes. Rotating by Count-n
+// moves the responses n indexes toward index 0.
+func (vec *BitVec) Rotate(n uint64) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	rotateSlots(vec.Data, vec.Layout, vec.Count, vec.Size, n)
+	vec.modified()
+}
+
+// InsertAt is a method of DiBit that inserts a response with the given state at the given index,
+// moving the responses from the index onwards up by one and increasing the Count by one.
+// Returns an error if the index is greater than the Count or if the state value exceeds the maximum for the DiBit.
+// All other methods check their indexes against the Count under the mutex, so they may run concurrently.
+func (vec *DiBit) InsertAt(index, state uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index, where the Count appends a response
+	if index > vec.Count {
+		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for DiBit
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	vec.Data = insertSlot(vec.Data, vec.Layout, vec.Count, DIBITSIZE, index, state)
+	vec.Count++
+	vec.modified()
+
+	return nil
+}
+
+// DeleteAt is a method of DiBit that removes the response at the given index,
+// moving the later responses down by one and decreasing the Count by one.
+// Returns an error if the index is out of bounds.
+func (vec *DiBit) DeleteAt(index uint64) error {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	vec.Data = deleteSlot(vec.Data, vec.Layout, vec.Count, DIBITSIZE, index)
+	vec.Count--
+	vec.modified()
+
+	return nil
+}
+
+// ShiftLeft is a method of DiBit that moves all responses n indexes toward index 0.
+// The first n responses are discarded and the last n responses are unset.
+func (vec *DiBit) ShiftLeft(n uint64) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	shiftSlots(vec.Data, vec.Layout, vec.Count, DIBITSIZE, n, true)
+	vec.modified()
+}
+
+// ShiftRight is a method of DiBit that moves all responses n indexes toward the last index.
+// The last n responses are discarded and the first n responses are unset.
+func (vec *DiBit) ShiftRight(n uint64) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	shiftSlots(vec.Data, vec.Layout, vec.Count, DIBITSIZE, n, false)
+	vec.modified()
+}
+
+// Rotate is a method of DiBit that moves all responses n indexes toward the last index,
+// with the last n responses wrapping around to the first indexes. Rotating by Count-n
+// moves the responses n indexes toward index 0.
+func (vec *DiBit) Rotate(n uint64) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	rotateSlots(vec.Data, vec.Layout, vec.Count, DIBITSIZE, n)
+	vec.modified()
+}
@@ -0,0 +1,212 @@
+package bitvec
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packStates returns the Data of a BitVec holding the states in the given layout.
+func packStates(size uint64, layout Layout, states []uint64) []uint64 {
+	vec, _ := NewBitVecFromStates(size, states)
+	_ = vec.Relayout(layout)
+
+	return vec.Data
+}
+
+func TestBitVec_InsertDelete(t *testing.T) {
+	rng := rand.New(rand.NewSource(43))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 3, 8, 64} {
+			vec := randomBitVec(rng, 60, size, layout)
+			states := vec.ToStates()
+
+			for n := 0; n < 300; n++ {
+				if rng.Intn(2) == 0 || len(states) == 0 {
+					index, state := uint64(rng.Intn(len(states)+1)), rng.Uint64()&vec.MaxState()
+					require.Nil(t, vec.InsertAt(index, state))
+
+					states = append(states[:index], append([]uint64{state}, states[index:]...)...)
+				} else {
+					index := uint64(rng.Intn(len(states)))
+					require.Nil(t, vec.DeleteAt(index))
+
+					states = append(states[:index], states[index+1:]...)
+				}
+
+				// Count, Data length and padding bits match a freshly packed BitVec
+				require.Equal(t, uint64(len(states)), vec.Count)
+				require.Equal(t, packStates(size, layout, states), vec.Data)
+			}
+		}
+	}
+
+	vec, _ := NewBitVec(10, 2)
+	assert.EqualError(t, vec.InsertAt(11, 1), "index too large for bitvec count (max: 10)")
+	assert.EqualError(t, vec.InsertAt(10, 4), "state too large for bitvec state (max: 3)")
+	assert.EqualError(t, vec.DeleteAt(10), "index too large for bitvec count (max: 10)")
+
+	// The rank directory follows the new Count
+	require.Nil(t, vec.InsertAt(10, 3))
+	rank, err := vec.Rank(3, 11)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(1), rank)
+}
+
+func TestBitVec_InsertDeleteConcurrent(t *testing.T) {
+	vec, _ := NewBitVec(33, 2)
+	dibit := NewDiBit(33)
+
+	// Operations on the last indexes race with deletes that shrink the Data by a word,
+	// so they must either see the old Count or fail with an out of bounds error
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 1000; n++ {
+			_ = vec.DeleteAt(0)
+			_ = vec.InsertAt(0, 1)
+			_ = dibit.DeleteAt(0)
+			_ = dibit.InsertAt(0, 1)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 1000; n++ {
+			_ = vec.Set(32, 1)
+			_ = vec.Unset(32)
+			_, _ = vec.Has(32, 1)
+			_, _ = vec.State(32)
+			_, _ = vec.Indexes(1)
+			_ = vec.SetBatch([]Update{{Index: 32, State: 1}})
+			_ = vec.UnsetBatch([]uint64{32})
+			_ = vec.Swap(0, 32)
+			_, _ = vec.Add(32, 1, OverflowWrap)
+			_, _ = vec.Rank(1, 33)
+			_, _, _ = vec.IndexesPage(1, 33, 1)
+			_, _ = vec.LastIndex(0)
+			_, _ = vec.Slice(0, 33)
+			_ = vec.ToStates()
+
+			_ = dibit.Set(32, 1)
+			_ = dibit.Unset(32)
+			_, _ = dibit.Has(32, 1)
+			_, _ = dibit.State(32)
+			_, _ = dibit.Indexes(1)
+			_ = dibit.SetBatch([]Update{{Index: 32, State: 1}})
+			_ = dibit.Swap(0, 32)
+			_, _ = dibit.Add(32, 1, OverflowWrap)
+			_, _ = dibit.LastIndex(0)
+		}
+	}()
+
+	wg.Wait()
+
+	assert.Equal(t, uint64(33), vec.Count)
+	assert.Len(t, vec.Data, 2)
+	assert.Equal(t, uint64(33), dibit.Count)
+	assert.Len(t, dibit.Data, 2)
+}
+
+func TestBitVec_Shift(t *testing.T) {
+	rng := rand.New(rand.NewSource(43))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 5, 64} {
+			for _, n := range []uint64{0, 1, 13, 64, 99, 100, 150} {
+				vec := randomBitVec(rng, 100, size, layout)
+				states := vec.ToStates()
+
+				left := make([]uint64, 100)
+				right := make([]uint64, 100)
+				rotated := make([]uint64, 100)
+				for i := uint64(0); i < 100; i++ {
+					if i+n < 100 {
+						left[i] = states[i+n]
+					}
+
+					if i >= n {
+						right[i] = states[i-n]
+					}
+
+					rotated[(i+n)%100] = states[i]
+				}
+
+				vec.ShiftLeft(n)
+				assert.Equal(t, packStates(size, layout, left), vec.Data)
+
+				vec, _ = NewBitVecFromStates(size, states)
+				require.Nil(t, vec.Relayout(layout))
+				vec.ShiftRight(n)
+				assert.Equal(t, packStates(size, layout, right), vec.Data)
+
+				vec, _ = NewBitVecFromStates(size, states)
+				require.Nil(t, vec.Relayout(layout))
+				vec.Rotate(n)
+				assert.Equal(t, packStates(size, layout, rotated), vec.Data)
+			}
+		}
+	}
+
+	// Operations on an empty vector do nothing
+	vec, _ := NewBitVec(0, 3)
+	vec.ShiftLeft(2)
+	vec.ShiftRight(2)
+	vec.Rotate(2)
+	assert.Equal(t, []uint64{}, vec.Data)
+}
+
+func TestDiBit_Shift(t *testing.T) {
+	vec := NewDiBit(40)
+	for i := uint64(0); i < 40; i++ {
+		require.Nil(t, vec.Set(i, i%4))
+	}
+
+	states := func() []uint64 {
+		output := make([]uint64, vec.Count)
+		require.Nil(t, vec.StatesAt(rangeIndexes(vec.Count), output))
+		return output
+	}
+
+	require.Nil(t, vec.DeleteAt(0))
+	assert.Equal(t, uint64(39), vec.Count)
+	assert.Equal(t, []uint64{1, 2, 3, 0}, states()[:4])
+	assert.Equal(t, 2, len(vec.Data))
+
+	require.Nil(t, vec.InsertAt(0, 3))
+	require.Nil(t, vec.InsertAt(40, 2))
+	assert.Equal(t, uint64(41), vec.Count)
+	assert.Equal(t, 2, len(vec.Data))
+	assert.Equal(t, []uint64{3, 1, 2, 3}, states()[:4])
+	assert.Equal(t, uint64(2), states()[40])
+
+	vec.Rotate(1)
+	assert.Equal(t, []uint64{2, 3, 1, 2}, states()[:4])
+
+	vec.ShiftLeft(2)
+	assert.Equal(t, []uint64{1, 2, 3, 0}, states()[:4])
+	assert.Equal(t, []uint64{0, 0}, states()[39:])
+
+	vec.ShiftRight(40)
+	assert.Equal(t, append(make([]uint64, 40), 1), states())
+
+	assert.EqualError(t, vec.InsertAt(42, 1), "index too large for dibit count (max: 41)")
+	assert.EqualError(t, vec.InsertAt(0, 4), "state too large for dibit state (max: 3)")
+	assert.EqualError(t, vec.DeleteAt(41), "index too large for dibit count (max: 41)")
+}
+
+// rangeIndexes returns the indexes from 0 to n-1.
+func rangeIndexes(n uint64) []uint64 {
+	indexes := make([]uint64, n)
+	for i := range indexes {
+		indexes[i] = uint64(i)
+	}
+
+	return indexes
+}
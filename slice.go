@@ -0,0 +1,257 @@
+package bitvec
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// checkRange returns an error if the range [from, to) is not within the count of a vector.
+func checkRange(from, to, count uint64, name string) error {
+	if from > to {
+		return errors.Errorf("range start after range end (start: %v, end: %v)", from, to)
+	}
+
+	if to > count {
+		return errors.Errorf("range end too large for %v count (max: %v)", name, count)
+	}
+
+	return nil
+}
+
+// slice returns a new BitVec with a copy of the responses in the range [from, to).
+// It must be called with the mutex held and a range within the Count.
+func (vec *BitVec) slice(from, to uint64) *BitVec {
+	// Error can be ignored because Size and Layout have already been checked
+	output, _ := NewBitVecWithLayout(to-from, vec.Size, vec.Layout)
+
+	copyBits(output.Data, 0, vec.Data, from*vec.Size, (to-from)*vec.Size, vec.Layout)
+	return output
+}
+
+// Slice is a method of BitVec that returns a new BitVec with a copy of the responses
+// in the range [from, to), so that the response at from is at index 0 of the new BitVec.
+// The new BitVec has the same Size and Layout. Returns an error if the range is not within the Count.
+func (vec *BitVec) Slice(from, to uint64) (*BitVec, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for a range out of bounds
+	if err := checkRange(from, to, vec.Count, "bitvec"); err != nil {
+		return nil, err
+	}
+
+	return vec.slice(from, to), nil
+}
+
+// BitVecView is a window over a contiguous range of the responses of a BitVec that shares its Data.
+// Index 0 of the view is the response at the start of the range in the BitVec, and all operations
+// on the view are performed on the BitVec, under its mutex. If responses are deleted from the BitVec
+// such that the range is no longer within its Count, all operations on the view return an error.
+type BitVecView struct {
+	// vec is the BitVec whose responses are viewed
+	vec *BitVec
+	// from is the index of the first response of the view in the BitVec
+	from uint64
+	// count is the number of responses of the view
+	count uint64
+}
+
+// View is a method of BitVec that returns a BitVecView over the responses in the range [from, to).
+// Returns an error if the range is not within the Count.
+func (vec *BitVec) View(from, to uint64) (*BitVecView, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for a range out of bounds
+	if err := checkRange(from, to, vec.Count, "bitvec"); err != nil {
+		return nil, err
+	}
+
+	return &BitVecView{vec: vec, from: from, count: to - from}, nil
+}
+
+// String implements the Stringer interface for BitVecView
+func (view *BitVecView) String() string {
+	return fmt.Sprintf("[%v|%v] view [%v, %v)", view.count, view.vec.Size, view.from, view.from+view.count)
+}
+
+// Count is a method of BitVecView that returns the number of responses in the view.
+func (view *BitVecView) Count() uint64 {
+	return view.count
+}
+
+// Offset is a method of BitVecView that returns the index in the BitVec of the first response in the view.
+func (view *BitVecView) Offset() uint64 {
+	return view.from
+}
+
+// MaxState is a method of BitVecView that returns the maximum value for a state of the BitVec.
+func (view *BitVecView) MaxState() uint64 {
+	return view.vec.MaxState()
+}
+
+// valid returns an error if the range of the view is no longer within the Count of the BitVec,
+// after responses were deleted from it. It must be called with the mutex of the BitVec held.
+func (view *BitVecView) valid() error {
+	if view.from+view.count > view.vec.Count {
+		return errors.Errorf("bitvec view range end too large for bitvec count (max: %v)", view.vec.Count)
+	}
+
+	return nil
+}
+
+// Set is a method of BitVecView that sets a given state at given index of the view.
+// Returns an error if the index is out of bounds for the view, if the state value exceeds the maximum
+// or if the view is no longer within the BitVec.
+func (view *BitVecView) Set(index, state uint64) error {
+	vec := view.vec
+
+	// Acquire the mutex of the BitVec
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= view.count {
+		return errors.Errorf("index too large for bitvec view count (max: %v)", view.count)
+	}
+
+	// Check that the view is still within the BitVec
+	if err := view.valid(); err != nil {
+		return err
+	}
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	pos := (view.from + index) * vec.Size
+	writeBits(vec.Data, vec.Layout, pos, vec.Size, readBits(vec.Data, vec.Layout, pos, vec.Size)|state)
+	vec.modified()
+
+	return nil
+}
+
+// Unset is a method of BitVecView that unsets the state for a given index of the view.
+// Returns an error index is out of bounds for the view or if the view is no longer within the BitVec.
+func (view *BitVecView) Unset(index uint64) error {
+	vec := view.vec
+
+	// Acquire the mutex of the BitVec
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= view.count {
+		return errors.Errorf("index too large for bitvec view count (max: %v)", view.count)
+	}
+
+	// Check that the view is still within the BitVec
+	if err := view.valid(); err != nil {
+		return err
+	}
+
+	writeBits(vec.Data, vec.Layout, (view.from+index)*vec.Size, vec.Size, 0)
+	vec.modified()
+
+	return nil
+}
+
+// Has is a method of BitVecView that checks whether the state at a given index of the view matches the given state.
+// Returns an error if the index is out of bounds for the view, if the state value exceeds the maximum
+// or if the view is no longer within the BitVec.
+func (view *BitVecView) Has(index, state uint64) (bool, error) {
+	vec := view.vec
+
+	// Acquire the mutex of the BitVec
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= view.count {
+		return false, errors.Errorf("index too large for bitvec view count (max: %v)", view.count)
+	}
+
+	// Check that the view is still within the BitVec
+	if err := view.valid(); err != nil {
+		return false, err
+	}
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	return readBits(vec.Data, vec.Layout, (view.from+index)*vec.Size, vec.Size) == state, nil
+}
+
+// State is a method of BitVecView that returns the state at a given index of the view.
+// Returns an error if the index is out of bounds for the view or if the view is no longer within the BitVec.
+func (view *BitVecView) State(index uint64) (uint64, error) {
+	vec := view.vec
+
+	// Acquire the mutex of the BitVec
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check for out of bounds index
+	if index >= view.count {
+		return 0, errors.Errorf("index too large for bitvec view count (max: %v)", view.count)
+	}
+
+	// Check that the view is still within the BitVec
+	if err := view.valid(); err != nil {
+		return 0, err
+	}
+
+	return readBits(vec.Data, vec.Layout, (view.from+index)*vec.Size, vec.Size), nil
+}
+
+// Indexes is a method of BitVecView that returns the slice of indexes of the view matching the given state.
+// Returns an error if state value exceeds the maximum or if the view is no longer within the BitVec.
+func (view *BitVecView) Indexes(state uint64) ([]uint64, error) {
+	vec := view.vec
+
+	// Check for state value too large for BitVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex of the BitVec
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check that the view is still within the BitVec
+	if err := view.valid(); err != nil {
+		return nil, err
+	}
+
+	indexes := make([]uint64, 0)
+	for i := uint64(0); i < view.count; i++ {
+		if readBits(vec.Data, vec.Layout, (view.from+i)*vec.Size, vec.Size) == state {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
+
+// Slice is a method of BitVecView that returns a new BitVec with a copy of the responses in the view.
+// Returns an error if the view is no longer within the BitVec.
+func (view *BitVecView) Slice() (*BitVec, error) {
+	vec := view.vec
+
+	// Acquire the mutex of the BitVec
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Check that the view is still within the BitVec
+	if err := view.valid(); err != nil {
+		return nil, err
+	}
+
+	return vec.slice(view.from, view.from+view.count), nil
+}
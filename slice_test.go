@@ -0,0 +1,143 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitVec_Slice(t *testing.T) {
+	rng := rand.New(rand.NewSource(41))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 3, 13, 64} {
+			vec, err := NewBitVecWithLayout(400, size, layout)
+			require.Nil(t, err, "Unexpected Error")
+
+			for n := 0; n < 300; n++ {
+				require.Nil(t, vec.Set(uint64(rng.Intn(400)), rng.Uint64()&vec.MaxState()))
+			}
+
+			states := vec.ToStates()
+			for n := 0; n < 20; n++ {
+				from := uint64(rng.Intn(400))
+				to := from + uint64(rng.Intn(400-int(from)+1))
+
+				output, err := vec.Slice(from, to)
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, to-from, output.Count)
+				assert.Equal(t, layout, output.Layout)
+				assert.Equal(t, states[from:to], output.ToStates())
+
+				// The padding bits of the new BitVec are clear
+				expected, _ := NewBitVecFromStates(size, states[from:to])
+				require.Nil(t, expected.Relayout(layout))
+				assert.Equal(t, expected.Data, output.Data)
+			}
+		}
+	}
+
+	vec, _ := NewBitVec(10, 2)
+
+	_, err := vec.Slice(5, 11)
+	assert.EqualError(t, err, "range end too large for bitvec count (max: 10)")
+
+	_, err = vec.Slice(6, 5)
+	assert.EqualError(t, err, "range start after range end (start: 6, end: 5)")
+
+	output, err := vec.Slice(10, 10)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(0), output.Count)
+}
+
+func TestBitVec_View(t *testing.T) {
+	vec, _ := NewBitVec(100, 5)
+	require.Nil(t, vec.Set(12, 31))
+	require.Nil(t, vec.Set(13, 7))
+	require.Nil(t, vec.Set(50, 7))
+
+	// The view starts in the middle of the first word and a response
+	view, err := vec.View(12, 60)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(48), view.Count())
+	assert.Equal(t, uint64(12), view.Offset())
+	assert.Equal(t, uint64(31), view.MaxState())
+	assert.Equal(t, "[48|5] view [12, 60)", view.String())
+
+	state, err := view.State(0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(31), state)
+
+	indexes, err := view.Indexes(7)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{1, 38}, indexes)
+
+	// Writes to the view are writes to the BitVec
+	require.Nil(t, view.Set(47, 9))
+	require.Nil(t, view.Unset(1))
+
+	exists, err := vec.Has(59, 9)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+
+	exists, err = view.Has(1, 0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+
+	output, err := view.Slice()
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(48), output.Count)
+	state, _ = output.State(47)
+	assert.Equal(t, uint64(9), state)
+
+	assert.EqualError(t, view.Set(48, 1), "index too large for bitvec view count (max: 48)")
+	assert.EqualError(t, view.Unset(48), "index too large for bitvec view count (max: 48)")
+
+	_, err = view.Has(48, 1)
+	assert.EqualError(t, err, "index too large for bitvec view count (max: 48)")
+
+	_, err = view.State(48)
+	assert.EqualError(t, err, "index too large for bitvec view count (max: 48)")
+
+	_, err = view.Indexes(32)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 31)")
+
+	_, err = vec.View(50, 101)
+	assert.EqualError(t, err, "range end too large for bitvec count (max: 100)")
+}
+
+func TestBitVecView_Stale(t *testing.T) {
+	vec, _ := NewBitVec(33, 2)
+	view, err := vec.View(0, 33)
+	require.Nil(t, err, "Unexpected Error")
+
+	// Deleting a response shrinks the Data below the range of the view
+	require.Nil(t, vec.DeleteAt(0))
+
+	stale := "bitvec view range end too large for bitvec count (max: 32)"
+
+	_, err = view.Indexes(0)
+	assert.EqualError(t, err, stale)
+
+	output, err := view.Slice()
+	assert.EqualError(t, err, stale)
+	assert.Nil(t, output)
+
+	assert.EqualError(t, view.Set(32, 1), stale)
+	assert.EqualError(t, view.Unset(0), stale)
+
+	_, err = view.Has(0, 0)
+	assert.EqualError(t, err, stale)
+
+	_, err = view.State(0)
+	assert.EqualError(t, err, stale)
+
+	// The view is valid again once the responses are inserted back
+	require.Nil(t, vec.InsertAt(0, 3))
+
+	state, err := view.State(0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(3), state)
+}
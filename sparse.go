@@ -0,0 +1,289 @@
+package bitvec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SparseBitVec is a struct that maintains some number of responses like BitVec,
+// but only stores the words of its data that are not zero. Its memory use depends
+// on the number of responses with a non-zero state rather than on the Count.
+type SparseBitVec struct {
+	// mu is the thread safety mutex
+	mu sync.Mutex
+
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+	// Layout is the order in which responses are packed into the words
+	Layout Layout
+	// Words stores the non-zero words of the data according to their position.
+	// The data is laid out exactly like the Data of a BitVec.
+	Words map[uint64]uint64
+}
+
+// NewSparseBitVec is a constructor function for SparseBitVec with the default MSBFirst layout.
+// Returns an error if Size is greater than MAXVECSIZE
+func NewSparseBitVec(count, size uint64) (*SparseBitVec, error) {
+	return NewSparseBitVecWithLayout(count, size, MSBFirst)
+}
+
+// NewSparseBitVecWithLayout is a constructor function for SparseBitVec with the given Layout.
+// Returns an error if Size is greater than MAXVECSIZE or if the Layout is unknown.
+func NewSparseBitVecWithLayout(count, size uint64, layout Layout) (*SparseBitVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	return &SparseBitVec{
+		mu: sync.Mutex{}, Count: count, Size: size, Layout: layout,
+		Words: make(map[uint64]uint64),
+	}, nil
+}
+
+// NewSparseBitVecFromBitVec is a constructor function for SparseBitVec with the responses of a BitVec.
+func NewSparseBitVecFromBitVec(vec *BitVec) *SparseBitVec {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	sparse := &SparseBitVec{
+		mu: sync.Mutex{}, Count: vec.Count, Size: vec.Size, Layout: vec.Layout,
+		Words: make(map[uint64]uint64),
+	}
+
+	for i, word := range vec.Data {
+		if word != 0 {
+			sparse.Words[uint64(i)] = word
+		}
+	}
+
+	return sparse
+}
+
+// ToBitVec is a method of SparseBitVec that returns a BitVec with the same responses.
+func (vec *SparseBitVec) ToBitVec() *BitVec {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Error can be ignored because the Size and Layout have already been checked
+	output, _ := NewBitVecWithLayout(vec.Count, vec.Size, vec.Layout)
+	for i, word := range vec.Words {
+		output.Data[i] = word
+	}
+
+	return output
+}
+
+// String implements the Stringer interface for SparseBitVec
+func (vec *SparseBitVec) String() string {
+	keys := vec.keys()
+
+	words := make([]string, len(keys))
+	for i, key := range keys {
+		words[i] = fmt.Sprintf("%v:%064b", key, vec.Words[key])
+	}
+
+	return fmt.Sprintf("[%v|%v] %v", vec.Count, vec.Size, words)
+}
+
+// MaxState is a method of SparseBitVec that returns the maximum value for a state for that SparseBitVec.
+// It is calculated as 2^StateBits-1.
+func (vec *SparseBitVec) MaxState() uint64 {
+	return 1<<vec.Size - 1
+}
+
+// keys returns the positions of the non-zero words in ascending order.
+func (vec *SparseBitVec) keys() []uint64 {
+	keys := make([]uint64, 0, len(vec.Words))
+	for key := range vec.Words {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// load returns the (at most) two words that hold the response at the given index
+// and the position of the response within them.
+func (vec *SparseBitVec) load(index uint64) ([2]uint64, uint64) {
+	pos := index * vec.Size
+	word := pos / 64
+
+	// Missing words are zero
+	return [2]uint64{vec.Words[word], vec.Words[word+1]}, pos % 64
+}
+
+// store writes back the two words that hold the response at the given index,
+// dropping words that are zero.
+func (vec *SparseBitVec) store(index uint64, words [2]uint64) {
+	word := index * vec.Size / 64
+
+	for i, value := range words {
+		if value == 0 {
+			delete(vec.Words, word+uint64(i))
+		} else {
+			vec.Words[word+uint64(i)] = value
+		}
+	}
+}
+
+// read returns the state of the response at the given index.
+func (vec *SparseBitVec) read(index uint64) uint64 {
+	words, pos := vec.load(index)
+	return readBits(words[:], vec.Layout, pos, vec.Size)
+}
+
+// Set is a method of SparseBitVec that sets a given state at given index.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the SparseBitVec.
+func (vec *SparseBitVec) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for sparse bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for SparseBitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for sparse bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Merge the state into the bits of the response in its words.
+	// NOTE: A response spans at most 2 words. This is regulated by MAXVECSIZE.
+	words, pos := vec.load(index)
+	writeBits(words[:], vec.Layout, pos, vec.Size, readBits(words[:], vec.Layout, pos, vec.Size)|state)
+	vec.store(index, words)
+
+	return nil
+}
+
+// Unset is a method of SparseBitVec that unsets the state for a given index.
+// Words that become zero are dropped. Returns an error index is out of bounds.
+func (vec *SparseBitVec) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for sparse bitvec count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Clear the bits of the response in its words
+	words, pos := vec.load(index)
+	writeBits(words[:], vec.Layout, pos, vec.Size, 0)
+	vec.store(index, words)
+
+	return nil
+}
+
+// Has is a method of SparseBitVec that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the SparseBitVec.
+func (vec *SparseBitVec) Has(index, state uint64) (bool, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return false, errors.Errorf("index too large for sparse bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for SparseBitVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for sparse bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.read(index) == state, nil
+}
+
+// State is a method of SparseBitVec that returns the state at a given index.
+// Returns an error if the index is out of bounds.
+func (vec *SparseBitVec) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for sparse bitvec count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return vec.read(index), nil
+}
+
+// Indexes is a method of SparseBitVec that returns the slice of indexes matching the given state.
+// Only the responses in the stored words are examined, all others are known to have the state 0.
+// Returns an error if state value exceeds the maximum for the SparseBitVec.
+func (vec *SparseBitVec) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for SparseBitVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for sparse bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	indexes := make([]uint64, 0)
+
+	// With a Size of 0 every response has the state 0
+	if vec.Size == 0 {
+		for i := uint64(0); i < vec.Count; i++ {
+			indexes = append(indexes, i)
+		}
+
+		return indexes, nil
+	}
+
+	// Iterate over the stored words in order, checking the responses that
+	// overlap each one. The responses in between them all have the state 0.
+	var next uint64
+	for _, word := range vec.keys() {
+		first, last := word*64/vec.Size, ((word+1)*64-1)/vec.Size
+		if last >= vec.Count {
+			last = vec.Count - 1
+		}
+
+		// A response straddling from the previous word has already been checked
+		if first < next {
+			first = next
+		}
+
+		if state == 0 {
+			for i := next; i < first; i++ {
+				indexes = append(indexes, i)
+			}
+		}
+
+		for i := first; i <= last; i++ {
+			if vec.read(i) == state {
+				indexes = append(indexes, i)
+			}
+		}
+
+		next = last + 1
+	}
+
+	if state == 0 {
+		for i := next; i < vec.Count; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
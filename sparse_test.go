@@ -0,0 +1,166 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSparseBitVec(t *testing.T) {
+	vec, err := NewSparseBitVec(1_000_000_000, 3)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Empty(t, vec.Words)
+	assert.Equal(t, uint64(7), vec.MaxState())
+
+	vec, err = NewSparseBitVec(10, 70)
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+	assert.Nil(t, vec)
+
+	vec, err = NewSparseBitVecWithLayout(10, 7, Layout(2))
+	assert.EqualError(t, err, "unknown bit layout: 2")
+	assert.Nil(t, vec)
+}
+
+func TestSparseBitVec_Set(t *testing.T) {
+	tests := []struct {
+		sparse   *SparseBitVec
+		idx, val uint64
+		output   map[uint64]uint64
+		err      string
+	}{
+		{
+			&SparseBitVec{Count: 32, Size: 2, Words: map[uint64]uint64{}},
+			30, 3, map[uint64]uint64{0: 12}, "",
+		},
+		{
+			&SparseBitVec{Count: 32, Size: 2, Words: map[uint64]uint64{0: 12}},
+			10, 2, map[uint64]uint64{0: 8796093022220}, "",
+		},
+		{
+			&SparseBitVec{Count: 42, Size: 3, Words: map[uint64]uint64{}},
+			21, 5, map[uint64]uint64{0: 1, 1: 4611686018427387904}, "",
+		},
+		{
+			&SparseBitVec{Count: 42, Size: 3, Layout: LSBFirst, Words: map[uint64]uint64{}},
+			21, 5, map[uint64]uint64{0: 9223372036854775808, 1: 2}, "",
+		},
+		{
+			&SparseBitVec{Count: 1 << 40, Size: 8, Words: map[uint64]uint64{}},
+			1<<40 - 1, 1, map[uint64]uint64{1<<37 - 1: 1}, "",
+		},
+		{
+			&SparseBitVec{Count: 10, Size: 4, Words: map[uint64]uint64{}},
+			30, 12, map[uint64]uint64{}, "index too large for sparse bitvec count (max: 10)",
+		},
+		{
+			&SparseBitVec{Count: 10, Size: 4, Words: map[uint64]uint64{}},
+			9, 18, map[uint64]uint64{}, "state too large for sparse bitvec state (max: 15)",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.sparse.Set(test.idx, test.val)
+		assert.Equal(t, test.output, test.sparse.Words)
+
+		if test.err == "" {
+			assert.Nil(t, err, "Unexpected Error")
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestSparseBitVec_Unset(t *testing.T) {
+	tests := []struct {
+		sparse *SparseBitVec
+		idx    uint64
+		output map[uint64]uint64
+		err    string
+	}{
+		{
+			&SparseBitVec{Count: 32, Size: 2, Words: map[uint64]uint64{0: 8796093022220}},
+			10, map[uint64]uint64{0: 12}, "",
+		},
+		{
+			&SparseBitVec{Count: 42, Size: 3, Words: map[uint64]uint64{0: 1, 1: 4611686018427387904}},
+			21, map[uint64]uint64{}, "",
+		},
+		{
+			&SparseBitVec{Count: 10, Size: 4, Words: map[uint64]uint64{}},
+			30, map[uint64]uint64{}, "index too large for sparse bitvec count (max: 10)",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.sparse.Unset(test.idx)
+		assert.Equal(t, test.output, test.sparse.Words)
+
+		if test.err == "" {
+			assert.Nil(t, err, "Unexpected Error")
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestSparseBitVec_State(t *testing.T) {
+	sparse := &SparseBitVec{Count: 42, Size: 3, Words: map[uint64]uint64{0: 1, 1: 4611686018427387904}}
+
+	state, err := sparse.State(21)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(5), state)
+
+	exists, err := sparse.Has(21, 5)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+
+	exists, err = sparse.Has(20, 0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.True(t, exists)
+
+	_, err = sparse.State(42)
+	assert.EqualError(t, err, "index too large for sparse bitvec count (max: 42)")
+
+	_, err = sparse.Has(1, 8)
+	assert.EqualError(t, err, "state too large for sparse bitvec state (max: 7)")
+}
+
+func TestSparseBitVec_Indexes(t *testing.T) {
+	rng := rand.New(rand.NewSource(32))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		vec, err := NewBitVecWithLayout(2000, 7, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		sparse, err := NewSparseBitVecWithLayout(2000, 7, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		for n := 0; n < 100; n++ {
+			index, state := uint64(rng.Intn(2000)), uint64(rng.Intn(128))
+			require.Nil(t, vec.Set(index, state))
+			require.Nil(t, sparse.Set(index, state))
+		}
+
+		assert.Equal(t, vec.Data, sparse.ToBitVec().Data)
+		assert.Equal(t, sparse.Words, NewSparseBitVecFromBitVec(vec).Words)
+
+		for _, state := range []uint64{0, 1, 5, 127} {
+			expected, err := vec.Indexes(state)
+			require.Nil(t, err, "Unexpected Error")
+
+			indexes, err := sparse.Indexes(state)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, expected, indexes)
+		}
+	}
+
+	sparse := &SparseBitVec{Count: 3, Size: 0, Words: map[uint64]uint64{}}
+	indexes, err := sparse.Indexes(0)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0, 1, 2}, indexes)
+
+	_, err = sparse.Indexes(1)
+	assert.EqualError(t, err, "state too large for sparse bitvec state (max: 0)")
+}
@@ -0,0 +1,381 @@
+package bitvec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// WordStore is a storage backend for the words of a StoreBitVec. The words are
+// laid out exactly like the Data of a BitVec, so any storage that can load and
+// store 64-bit words by position can hold a vector without reimplementing the
+// bit manipulation of its responses.
+//
+// A WordStore is only accessed by one goroutine at a time through a StoreBitVec.
+type WordStore interface {
+	// Load returns the word at position i
+	Load(i uint64) (uint64, error)
+	// Store sets the word at position i
+	Store(i, word uint64) error
+	// Len returns the number of words
+	Len() uint64
+}
+
+// MemoryStore is a WordStore that keeps the words in memory, like the Data of a BitVec.
+type MemoryStore []uint64
+
+// NewMemoryStore is a constructor function for a MemoryStore with n zero words.
+func NewMemoryStore(n uint64) MemoryStore {
+	return make(MemoryStore, n)
+}
+
+// Load implements the WordStore interface for MemoryStore
+func (store MemoryStore) Load(i uint64) (uint64, error) {
+	return store[i], nil
+}
+
+// Store implements the WordStore interface for MemoryStore
+func (store MemoryStore) Store(i, word uint64) error {
+	store[i] = word
+	return nil
+}
+
+// Len implements the WordStore interface for MemoryStore
+func (store MemoryStore) Len() uint64 {
+	return uint64(len(store))
+}
+
+// SparseStore is a WordStore that only keeps the words that are not zero, like SparseBitVec.
+type SparseStore struct {
+	// Words stores the non-zero words according to their position
+	Words map[uint64]uint64
+	// N is the number of words
+	N uint64
+}
+
+// NewSparseStore is a constructor function for a SparseStore with n zero words.
+func NewSparseStore(n uint64) *SparseStore {
+	return &SparseStore{Words: make(map[uint64]uint64), N: n}
+}
+
+// Load implements the WordStore interface for SparseStore
+func (store *SparseStore) Load(i uint64) (uint64, error) {
+	return store.Words[i], nil
+}
+
+// Store implements the WordStore interface for SparseStore
+func (store *SparseStore) Store(i, word uint64) error {
+	if word == 0 {
+		delete(store.Words, i)
+	} else {
+		store.Words[i] = word
+	}
+
+	return nil
+}
+
+// Len implements the WordStore interface for SparseStore
+func (store *SparseStore) Len() uint64 {
+	return store.N
+}
+
+// FileStore is a WordStore that reads and writes every word directly in a file, encoded little-endian.
+// With an Offset of BINARYHEADERSIZE it operates on a file in the binary serialization format.
+type FileStore struct {
+	// File is the file holding the words
+	File io.ReaderAt
+	// Offset is the position of the first word in the file
+	Offset int64
+	// N is the number of words
+	N uint64
+}
+
+// NewFileStore is a constructor function for a FileStore with n words starting at offset in file.
+// The file must also implement io.WriterAt for words to be stored, such as an *os.File opened for writing.
+func NewFileStore(file io.ReaderAt, offset int64, n uint64) *FileStore {
+	return &FileStore{File: file, Offset: offset, N: n}
+}
+
+// Load implements the WordStore interface for FileStore
+func (store *FileStore) Load(i uint64) (uint64, error) {
+	var buf [8]byte
+	if _, err := store.File.ReadAt(buf[:], store.Offset+int64(i)*8); err != nil {
+		return 0, errors.Wrapf(err, "failed to load word %v", i)
+	}
+
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// Store implements the WordStore interface for FileStore
+func (store *FileStore) Store(i, word uint64) error {
+	writer, ok := store.File.(io.WriterAt)
+	if !ok {
+		return errors.New("file store is read-only")
+	}
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], word)
+	if _, err := writer.WriteAt(buf[:], store.Offset+int64(i)*8); err != nil {
+		return errors.Wrapf(err, "failed to store word %v", i)
+	}
+
+	return nil
+}
+
+// Len implements the WordStore interface for FileStore
+func (store *FileStore) Len() uint64 {
+	return store.N
+}
+
+// loadBits returns the n bits (at most 64) starting at bit position pos of the words in the store.
+// It loads the (at most two) words holding the bits and reads them like readBits.
+func loadBits(store WordStore, layout Layout, pos, n uint64) (uint64, error) {
+	words, err := loadWindow(store, pos, n)
+	if err != nil {
+		return 0, err
+	}
+
+	return readBits(words[:], layout, pos%64, n), nil
+}
+
+// storeBits overwrites the n bits (at most 64) starting at bit position pos of the words in the store.
+// It loads the (at most two) words holding the bits, writes them like writeBits and stores them back.
+func storeBits(store WordStore, layout Layout, pos, n, value uint64) error {
+	words, err := loadWindow(store, pos, n)
+	if err != nil {
+		return err
+	}
+
+	writeBits(words[:], layout, pos%64, n, value)
+
+	// Store back the words that hold the bits
+	word := pos / 64
+	for i := uint64(0); i < 2 && word+i <= (pos+n-1)/64; i++ {
+		if err := store.Store(word+i, words[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadWindow loads the (at most two) words of the store holding the n bits at bit position pos.
+func loadWindow(store WordStore, pos, n uint64) ([2]uint64, error) {
+	var words [2]uint64
+	if n == 0 {
+		return words, nil
+	}
+
+	word := pos / 64
+	for i := uint64(0); i < 2 && word+i <= (pos+n-1)/64; i++ {
+		value, err := store.Load(word + i)
+		if err != nil {
+			return words, err
+		}
+
+		words[i] = value
+	}
+
+	return words, nil
+}
+
+// StoreBitVec is a struct that maintains some number of responses like BitVec,
+// with the words of its data kept in a pluggable WordStore instead of a slice.
+//
+// It shares the bit manipulation of BitVec, but only supports Set, Unset, Has, State and Indexes.
+// BitVec itself keeps its Data as a slice, because its other operations, such as batches, rank,
+// search and slicing, work on whole words of the Data in place. ToBitVec returns a BitVec with
+// a copy of the responses to run any of those operations on.
+type StoreBitVec struct {
+	// mu is the thread safety mutex
+	mu sync.Mutex
+
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+	// Layout is the order in which responses are packed into the words
+	Layout Layout
+	// Store holds the words of the data
+	Store WordStore
+}
+
+// NewStoreBitVec is a constructor function for StoreBitVec with the default MSBFirst layout.
+// Returns an error if Size is greater than MAXVECSIZE or if the store holds too few words.
+func NewStoreBitVec(count, size uint64, store WordStore) (*StoreBitVec, error) {
+	return NewStoreBitVecWithLayout(count, size, MSBFirst, store)
+}
+
+// NewStoreBitVecWithLayout is a constructor function for StoreBitVec with the given Layout.
+// Returns an error if Size is greater than MAXVECSIZE, if the Layout is unknown or if the store holds too few words.
+func NewStoreBitVecWithLayout(count, size uint64, layout Layout, store WordStore) (*StoreBitVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	// Check that the words for the Count and Size can be addressed
+	if err := checkCount(count, size); err != nil {
+		return nil, err
+	}
+
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	// Check that the store holds all the words
+	if words := (count*size + 63) / 64; store.Len() < words {
+		return nil, errors.Errorf("word store too small for count and size (want: %v)", words)
+	}
+
+	return &StoreBitVec{mu: sync.Mutex{}, Count: count, Size: size, Layout: layout, Store: store}, nil
+}
+
+// String implements the Stringer interface for StoreBitVec
+func (vec *StoreBitVec) String() string {
+	return fmt.Sprintf("[%v|%v] %T(%v)", vec.Count, vec.Size, vec.Store, vec.Store.Len())
+}
+
+// MaxState is a method of StoreBitVec that returns the maximum value for a state for that StoreBitVec.
+// It is calculated as 2^StateBits-1.
+func (vec *StoreBitVec) MaxState() uint64 {
+	return 1<<vec.Size - 1
+}
+
+// Set is a method of StoreBitVec that sets a given state at given index.
+// Returns an error if the index is out of bounds, if the state value exceeds the maximum for the StoreBitVec
+// or if the WordStore fails.
+func (vec *StoreBitVec) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for store bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for StoreBitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for store bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Merge the state into the bits of the response
+	pos := index * vec.Size
+	current, err := loadBits(vec.Store, vec.Layout, pos, vec.Size)
+	if err != nil {
+		return err
+	}
+
+	return storeBits(vec.Store, vec.Layout, pos, vec.Size, current|state)
+}
+
+// Unset is a method of StoreBitVec that unsets the state for a given index.
+// Returns an error index is out of bounds or if the WordStore fails.
+func (vec *StoreBitVec) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for store bitvec count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return storeBits(vec.Store, vec.Layout, index*vec.Size, vec.Size, 0)
+}
+
+// Has is a method of StoreBitVec that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds, if the state value exceeds the maximum for the StoreBitVec
+// or if the WordStore fails.
+func (vec *StoreBitVec) Has(index, state uint64) (bool, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return false, errors.Errorf("index too large for store bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for StoreBitVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for store bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	value, err := loadBits(vec.Store, vec.Layout, index*vec.Size, vec.Size)
+	if err != nil {
+		return false, err
+	}
+
+	return value == state, nil
+}
+
+// State is a method of StoreBitVec that returns the state at a given index.
+// Returns an error if the index is out of bounds or if the WordStore fails.
+func (vec *StoreBitVec) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for store bitvec count (max: %v)", vec.Count)
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	return loadBits(vec.Store, vec.Layout, index*vec.Size, vec.Size)
+}
+
+// Indexes is a method of StoreBitVec that returns the slice of indexes matching the given state.
+// Returns an error if state value exceeds the maximum for the StoreBitVec or if the WordStore fails.
+func (vec *StoreBitVec) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for StoreBitVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for store bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	indexes := make([]uint64, 0)
+	for i := uint64(0); i < vec.Count; i++ {
+		value, err := loadBits(vec.Store, vec.Layout, i*vec.Size, vec.Size)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == state {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
+
+// ToBitVec is a method of StoreBitVec that returns a BitVec with a copy of the responses,
+// with the same Count, Size and Layout. Returns an error if the WordStore fails.
+func (vec *StoreBitVec) ToBitVec() (*BitVec, error) {
+	// Acquire the mutex
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	// Error can be ignored because Size and Layout have already been checked
+	output, _ := NewBitVecWithLayout(vec.Count, vec.Size, vec.Layout)
+
+	for w := range output.Data {
+		word, err := vec.Store.Load(uint64(w))
+		if err != nil {
+			return nil, err
+		}
+
+		// Clear any bits of the store beyond the responses
+		output.Data[w] = word & usedBits(vec.Layout, vec.Count*vec.Size, w)
+	}
+
+	return output, nil
+}
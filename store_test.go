@@ -0,0 +1,185 @@
+package bitvec
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreBitVec(t *testing.T) {
+	rng := rand.New(rand.NewSource(35))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 5, 13, 64} {
+			count := uint64(300)
+			words := (count*size + 63) / 64
+
+			vec, err := NewBitVecWithLayout(count, size, layout)
+			require.Nil(t, err, "Unexpected Error")
+
+			memory := NewMemoryStore(words)
+			sparse := NewSparseStore(words)
+
+			stores := make([]*StoreBitVec, 0)
+			for _, store := range []WordStore{memory, sparse} {
+				storeVec, err := NewStoreBitVecWithLayout(count, size, layout, store)
+				require.Nil(t, err, "Unexpected Error")
+
+				stores = append(stores, storeVec)
+			}
+
+			for n := 0; n < 200; n++ {
+				index, state := uint64(rng.Intn(int(count))), rng.Uint64()&vec.MaxState()
+				unset := rng.Intn(4) == 0
+
+				for _, storeVec := range stores {
+					if unset {
+						require.Nil(t, storeVec.Unset(index))
+					} else {
+						require.Nil(t, storeVec.Set(index, state))
+					}
+				}
+
+				if unset {
+					require.Nil(t, vec.Unset(index))
+				} else {
+					require.Nil(t, vec.Set(index, state))
+				}
+			}
+
+			// The stores hold the same words as the Data of the BitVec
+			assert.Equal(t, vec.Data, []uint64(memory))
+			for w, word := range vec.Data {
+				stored, _ := sparse.Load(uint64(w))
+				assert.Equal(t, word, stored)
+			}
+
+			for _, word := range sparse.Words {
+				assert.NotZero(t, word)
+			}
+
+			for _, storeVec := range stores {
+				for i := uint64(0); i < count; i++ {
+					expected, _ := vec.State(i)
+					state, err := storeVec.State(i)
+					require.Nil(t, err, "Unexpected Error")
+					assert.Equal(t, expected, state)
+
+					exists, err := storeVec.Has(i, expected)
+					require.Nil(t, err, "Unexpected Error")
+					assert.True(t, exists)
+				}
+
+				expected, _ := vec.Indexes(0)
+				indexes, err := storeVec.Indexes(0)
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, expected, indexes)
+
+				output, err := storeVec.ToBitVec()
+				require.Nil(t, err, "Unexpected Error")
+				assert.Equal(t, vec.Data, output.Data)
+				assert.Equal(t, vec.String(), output.String())
+			}
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	vec, err := NewBitVecWithLayout(50, 5, LSBFirst)
+	require.Nil(t, err, "Unexpected Error")
+
+	require.Nil(t, vec.Set(3, 17))
+	require.Nil(t, vec.Set(12, 31))
+
+	data, err := vec.MarshalBinary()
+	require.Nil(t, err, "Unexpected Error")
+
+	path := filepath.Join(t.TempDir(), "vec.bin")
+	require.Nil(t, os.WriteFile(path, data, 0o644))
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	require.Nil(t, err, "Unexpected Error")
+	defer file.Close()
+
+	// A FileStore after the header operates on the binary serialization format
+	storeVec, err := NewStoreBitVecWithLayout(50, 5, LSBFirst, NewFileStore(file, BINARYHEADERSIZE, 4))
+	require.Nil(t, err, "Unexpected Error")
+
+	state, err := storeVec.State(12)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(31), state)
+
+	require.Nil(t, storeVec.Set(40, 9))
+	require.Nil(t, storeVec.Unset(3))
+	require.Nil(t, vec.Set(40, 9))
+	require.Nil(t, vec.Unset(3))
+
+	data, err = os.ReadFile(path)
+	require.Nil(t, err, "Unexpected Error")
+
+	output := new(BitVec)
+	require.Nil(t, output.UnmarshalBinary(data))
+	assert.Equal(t, vec.Data, output.Data)
+
+	// A reader without WriteAt is read-only
+	readOnly, err := NewStoreBitVec(50, 5, NewFileStore(bytes.NewReader(data), BINARYHEADERSIZE, 4))
+	require.Nil(t, err, "Unexpected Error")
+	assert.EqualError(t, readOnly.Set(1, 1), "file store is read-only")
+
+	// Words beyond the end of the file fail to load
+	short, err := NewStoreBitVec(50, 5, NewFileStore(bytes.NewReader(data[:40]), BINARYHEADERSIZE, 4))
+	require.Nil(t, err, "Unexpected Error")
+
+	_, err = short.State(45)
+	assert.EqualError(t, err, "failed to load word 3: EOF")
+
+	_, err = short.ToBitVec()
+	assert.EqualError(t, err, "failed to load word 2: EOF")
+
+	// Bits of the store beyond the responses are not copied
+	padded, err := NewStoreBitVec(3, 5, MemoryStore{1<<64 - 1, 1<<64 - 1})
+	require.Nil(t, err, "Unexpected Error")
+
+	output, err = padded.ToBitVec()
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, []uint64{0xfffe000000000000}, output.Data)
+}
+
+func TestStoreBitVec_Errors(t *testing.T) {
+	_, err := NewStoreBitVec(10, 70, NewMemoryStore(20))
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+
+	_, err = NewStoreBitVec(1<<63, 2, NewMemoryStore(3))
+	assert.EqualError(t, err, "count too large for bitvec size (max: 9223372036854775776)")
+
+	_, err = NewStoreBitVec(100, 2, NewMemoryStore(3))
+	assert.EqualError(t, err, "word store too small for count and size (want: 4)")
+
+	_, err = NewStoreBitVecWithLayout(10, 2, Layout(7), NewMemoryStore(1))
+	assert.EqualError(t, err, "unknown bit layout: 7")
+
+	vec, err := NewStoreBitVec(10, 2, NewSparseStore(1))
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, "[10|2] *bitvec.SparseStore(1)", vec.String())
+
+	assert.EqualError(t, vec.Set(10, 1), "index too large for store bitvec count (max: 10)")
+	assert.EqualError(t, vec.Set(1, 4), "state too large for store bitvec state (max: 3)")
+	assert.EqualError(t, vec.Unset(10), "index too large for store bitvec count (max: 10)")
+
+	_, err = vec.Has(10, 1)
+	assert.EqualError(t, err, "index too large for store bitvec count (max: 10)")
+
+	_, err = vec.Has(1, 4)
+	assert.EqualError(t, err, "state too large for store bitvec state (max: 3)")
+
+	_, err = vec.State(10)
+	assert.EqualError(t, err, "index too large for store bitvec count (max: 10)")
+
+	_, err = vec.Indexes(4)
+	assert.EqualError(t, err, "state too large for store bitvec state (max: 3)")
+}
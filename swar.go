@@ -0,0 +1,120 @@
+package bitvec
+
+import "math/bits"
+
+// The helpers in this file treat a word of Data as a set of independent fields of
+// size bits each and operate on all of them at once (SIMD within a register).
+// They require size to divide 64 so that no slot straddles two words. Both layouts
+// place the fields on the same bit boundaries and store states with their usual bit
+// order, so only the mapping from a field to its slot index depends on the Layout.
+
+// aligned returns whether slots of size bits never straddle two words.
+func aligned(size uint64) bool {
+	return size != 0 && 64%size == 0
+}
+
+// fieldMasks returns the masks with the least and the most
+// significant bit of every size-bit field of a word set.
+func fieldMasks(size uint64) (low, high uint64) {
+	low = (1<<64 - 1) / bitMask(size)
+	return low, low << (size - 1)
+}
+
+// broadcast returns a word with every size-bit field set to state.
+func broadcast(size, state uint64) uint64 {
+	low, _ := fieldMasks(size)
+	return low * state
+}
+
+// zeroFields returns a word with the most significant bit of
+// every size-bit field of x set if that field is zero.
+func zeroFields(x, size uint64) uint64 {
+	_, high := fieldMasks(size)
+
+	// Adding the low bits of every field to a mask of all low bits carries into the
+	// high bit of the field if any of the low bits was set, without crossing fields.
+	return ^(((x &^ high) + ^high) | x) & high
+}
+
+// matchFields returns a word with the most significant bit of
+// every size-bit field of word set if that field equals state.
+func matchFields(word, size, state uint64) uint64 {
+	return zeroFields(word^broadcast(size, state), size)
+}
+
+// leadingBits returns the mask of the first n bits (at most 64) of a word in the given layout.
+// These are the n most significant bits for MSBFirst and the n least significant bits for LSBFirst.
+func leadingBits(layout Layout, n uint64) uint64 {
+	if layout == LSBFirst {
+		return bitMask(n)
+	}
+
+	return ^bitMask(64 - n)
+}
+
+// fieldSlot returns the index within its word of the slot whose field contains the given bit.
+func fieldSlot(layout Layout, size uint64, bit int) uint64 {
+	if layout == LSBFirst {
+		return uint64(bit) / size
+	}
+
+	return uint64(63-bit) / size
+}
+
+// firstField returns the bit of mask (which must not be zero) that belongs to the
+// earliest slot in the given layout and the mask with that bit cleared.
+func firstField(layout Layout, mask uint64) (int, uint64) {
+	if layout == LSBFirst {
+		bit := bits.TrailingZeros64(mask)
+		return bit, mask &^ (1 << bit)
+	}
+
+	bit := 63 - bits.LeadingZeros64(mask)
+	return bit, mask &^ (1 << bit)
+}
+
+// lastField returns the bit of mask (which must not be zero) that belongs to the
+// latest slot in the given layout and the mask with that bit cleared.
+func lastField(layout Layout, mask uint64) (int, uint64) {
+	if layout == LSBFirst {
+		bit := 63 - bits.LeadingZeros64(mask)
+		return bit, mask &^ (1 << bit)
+	}
+
+	bit := bits.TrailingZeros64(mask)
+	return bit, mask &^ (1 << bit)
+}
+
+// wordMatches returns the matchFields mask of word w of data for the given state, restricted
+// to the fields of slots that lie within the first used bits of data (the rest is padding).
+func wordMatches(data []uint64, layout Layout, size, used uint64, w int, state uint64) uint64 {
+	return matchFields(data[w], size, state) & usedBits(layout, used, w)
+}
+
+// usedBits returns the mask of the bits of word w that lie within the first used bits of data,
+// which clears the padding of the last word.
+func usedBits(layout Layout, used uint64, w int) uint64 {
+	if start := uint64(w) * 64; start+64 > used {
+		return leadingBits(layout, used-start)
+	}
+
+	return 1<<64 - 1
+}
+
+// maskedFields returns a word with the most significant bit of every
+// size-bit field of word set if that field masked with mask equals value.
+func maskedFields(word, size, mask, value uint64) uint64 {
+	return zeroFields(word&broadcast(size, mask)^broadcast(size, value), size)
+}
+
+// atLeastFields returns a word with the most significant bit of every size-bit
+// field of x set if that field is greater than or equal to the field of y.
+func atLeastFields(x, y, size uint64) uint64 {
+	_, high := fieldMasks(size)
+
+	// Subtracting the low bits of the fields of y from the fields of x with their high bit
+	// set leaves the high bit set if there is no borrow, without crossing fields. That decides
+	// the comparison if the high bits of both fields are equal, and the high bits decide otherwise.
+	r := (x | high) - (y &^ high)
+	return ((x &^ y) | (^(x ^ y) & r)) & high
+}
@@ -0,0 +1,75 @@
+package bitvec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchFields(t *testing.T) {
+	tests := []struct {
+		word, size, state, output uint64
+	}{
+		{0b1010, 1, 1, 0b1010},
+		{0b1010, 1, 0, 0xFFFFFFFFFFFFFFF5},
+		{0b11100100, 2, 0, 0xAAAAAAAAAAAAAA02},
+		{0b11100100, 2, 1, 0b1000},
+		{0b11100100, 2, 3, 0b10000000},
+		{0x00FF00FF00FF00FF, 8, 0xFF, 0x0080008000800080},
+		{0x0000000100000000, 32, 1, 0x8000000000000000},
+		{42, 64, 42, 0x8000000000000000},
+		{42, 64, 43, 0},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.output, matchFields(test.word, test.size, test.state))
+	}
+}
+
+func TestFirstField(t *testing.T) {
+	bit, rest := firstField(MSBFirst, 0b1010)
+	assert.Equal(t, 3, bit)
+	assert.Equal(t, uint64(0b0010), rest)
+	assert.Equal(t, uint64(30), fieldSlot(MSBFirst, 2, bit))
+
+	bit, rest = firstField(LSBFirst, 0b1010)
+	assert.Equal(t, 1, bit)
+	assert.Equal(t, uint64(0b1000), rest)
+	assert.Equal(t, uint64(0), fieldSlot(LSBFirst, 2, bit))
+}
+
+func TestLastField(t *testing.T) {
+	bit, rest := lastField(MSBFirst, 0b1010)
+	assert.Equal(t, 1, bit)
+	assert.Equal(t, uint64(0b1000), rest)
+	assert.Equal(t, uint64(31), fieldSlot(MSBFirst, 2, bit))
+
+	bit, rest = lastField(LSBFirst, 0b1010)
+	assert.Equal(t, 3, bit)
+	assert.Equal(t, uint64(0b0010), rest)
+	assert.Equal(t, uint64(1), fieldSlot(LSBFirst, 2, bit))
+}
+
+func TestAtLeastFields(t *testing.T) {
+	for _, size := range []uint64{1, 2, 4, 8, 16, 32, 64} {
+		max := bitMask(size)
+		for x := uint64(0); x <= max && x < 20; x++ {
+			for y := uint64(0); y <= max && y < 20; y++ {
+				// Every field of the word compares the same values
+				expected := uint64(0)
+				if x >= y {
+					_, expected = fieldMasks(size)
+				}
+
+				assert.Equal(t, expected, atLeastFields(broadcast(size, x), broadcast(size, y), size))
+			}
+		}
+
+		// The largest states compare correctly
+		_, high := fieldMasks(size)
+		assert.Equal(t, high, atLeastFields(broadcast(size, max), broadcast(size, max-1), size))
+		assert.Equal(t, uint64(0), atLeastFields(broadcast(size, max-1), broadcast(size, max), size))
+	}
+
+	assert.Equal(t, uint64(0x80808080_80_00_80_80), atLeastFields(0x05_03_FF_00, 0x04_04_80_00, 8))
+}
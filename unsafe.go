@@ -0,0 +1,254 @@
+package bitvec
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// UnsafeBitVec is a struct that maintains some number of responses like BitVec, without a mutex.
+// It is meant for vectors owned by a single goroutine and must not be modified concurrently.
+type UnsafeBitVec struct {
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+	// Layout is the order in which responses are packed into Data
+	Layout Layout
+	// Data stores the responses according to their indices
+	Data []uint64
+}
+
+// NewUnsafeBitVec is a constructor function for UnsafeBitVec with the default MSBFirst layout.
+// Returns an error if Size is greater than MAXVECSIZE
+func NewUnsafeBitVec(count, size uint64) (*UnsafeBitVec, error) {
+	return NewUnsafeBitVecWithLayout(count, size, MSBFirst)
+}
+
+// NewUnsafeBitVecWithLayout is a constructor function for UnsafeBitVec with the given Layout.
+// Returns an error if Size is greater than MAXVECSIZE or if the Layout is unknown.
+func NewUnsafeBitVecWithLayout(count, size uint64, layout Layout) (*UnsafeBitVec, error) {
+	// Check if given Size is under MAXVECSIZE
+	if size > MAXVECSIZE {
+		return nil, errors.New("state size greater 64 not allowed")
+	}
+
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	return &UnsafeBitVec{Count: count, Size: size, Layout: layout, Data: make([]uint64, (count*size+63)/64)}, nil
+}
+
+// String implements the Stringer interface for UnsafeBitVec
+func (vec *UnsafeBitVec) String() string {
+	return fmt.Sprintf("[%v|%v] %064b", vec.Count, vec.Size, vec.Data)
+}
+
+// MaxState is a method of UnsafeBitVec that returns the maximum value for a state for that UnsafeBitVec.
+// It is calculated as 2^StateBits-1.
+func (vec *UnsafeBitVec) MaxState() uint64 {
+	return 1<<vec.Size - 1
+}
+
+// Set is a method of UnsafeBitVec that sets a given state at given index.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the UnsafeBitVec.
+func (vec *UnsafeBitVec) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for UnsafeBitVec
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	// Merge the state into the bits of the response at its position in the Data
+	pos := index * vec.Size
+	writeBits(vec.Data, vec.Layout, pos, vec.Size, readBits(vec.Data, vec.Layout, pos, vec.Size)|state)
+
+	return nil
+}
+
+// Unset is a method of UnsafeBitVec that unsets the state for a given index.
+// Returns an error index is out of bounds.
+func (vec *UnsafeBitVec) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	// Clear the bits of the response at its position in the Data
+	writeBits(vec.Data, vec.Layout, index*vec.Size, vec.Size, 0)
+
+	return nil
+}
+
+// Has is a method of UnsafeBitVec that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the UnsafeBitVec.
+func (vec *UnsafeBitVec) Has(index, state uint64) (bool, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return false, errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for UnsafeBitVec
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	return readBits(vec.Data, vec.Layout, index*vec.Size, vec.Size) == state, nil
+}
+
+// State is a method of UnsafeBitVec that returns the state at a given index.
+// Returns an error if the index is out of bounds.
+func (vec *UnsafeBitVec) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for bitvec count (max: %v)", vec.Count)
+	}
+
+	return readBits(vec.Data, vec.Layout, index*vec.Size, vec.Size), nil
+}
+
+// Indexes is a method of UnsafeBitVec that returns the slice of indexes matching the given state.
+// Returns an error if state value exceeds the maximum for the UnsafeBitVec.
+func (vec *UnsafeBitVec) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for UnsafeBitVec
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for bitvec state (max: %v)", vec.MaxState())
+	}
+
+	indexes := make([]uint64, 0)
+	reader := slotReader{data: vec.Data, layout: vec.Layout, size: vec.Size}
+	for i := uint64(0); i < vec.Count; i++ {
+		if reader.read() == state {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
+
+// UnsafeDiBit is a struct that maintains some number of responses like DiBit, without a mutex.
+// It is meant for vectors owned by a single goroutine and must not be modified concurrently.
+type UnsafeDiBit struct {
+	// Count is the number of responses
+	Count uint64
+	// Layout is the order in which responses are packed into Data
+	Layout Layout
+	// Data stores the responses according to their indices
+	Data []uint64
+}
+
+// NewUnsafeDiBit is a constructor function for UnsafeDiBit with the default MSBFirst layout.
+func NewUnsafeDiBit(count uint64) *UnsafeDiBit {
+	return &UnsafeDiBit{Count: count, Data: make([]uint64, (count*DIBITSIZE+63)/64)}
+}
+
+// NewUnsafeDiBitWithLayout is a constructor function for UnsafeDiBit with the given Layout.
+// Returns an error if the Layout is unknown.
+func NewUnsafeDiBitWithLayout(count uint64, layout Layout) (*UnsafeDiBit, error) {
+	// Check if given Layout is known
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	vec := NewUnsafeDiBit(count)
+	vec.Layout = layout
+
+	return vec, nil
+}
+
+// String implements the Stringer interface for UnsafeDiBit
+func (vec *UnsafeDiBit) String() string {
+	return fmt.Sprintf("[%v] %064b", vec.Count, vec.Data)
+}
+
+// MaxState is a method of UnsafeDiBit that returns the maximum value for the state.
+// It is calculated as 2^StateBits-1.
+func (vec *UnsafeDiBit) MaxState() uint64 {
+	return 1<<DIBITSIZE - 1
+}
+
+// Set is a method of UnsafeDiBit that sets a given state at given index.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the UnsafeDiBit.
+func (vec *UnsafeDiBit) Set(index, state uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for UnsafeDiBit
+	if state > vec.MaxState() {
+		return errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	// Merge the state into the bits of the response at its position in the Data
+	pos := index * DIBITSIZE
+	writeBits(vec.Data, vec.Layout, pos, DIBITSIZE, readBits(vec.Data, vec.Layout, pos, DIBITSIZE)|state)
+
+	return nil
+}
+
+// Unset is a method of UnsafeDiBit that unsets the state for a given index.
+// Returns an error index is out of bounds.
+func (vec *UnsafeDiBit) Unset(index uint64) error {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	// Clear the bits of the response at its position in the Data
+	writeBits(vec.Data, vec.Layout, index*DIBITSIZE, DIBITSIZE, 0)
+
+	return nil
+}
+
+// Has is a method of UnsafeDiBit that checks whether the state at a given index matches the given state.
+// Returns an error if the index is out of bounds or if the state value exceeds the maximum for the UnsafeDiBit.
+func (vec *UnsafeDiBit) Has(index, state uint64) (bool, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return false, errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	// Check for state value too large for UnsafeDiBit
+	if state > vec.MaxState() {
+		return false, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	return readBits(vec.Data, vec.Layout, index*DIBITSIZE, DIBITSIZE) == state, nil
+}
+
+// State is a method of UnsafeDiBit that returns the state at a given index.
+// Returns an error if the index is out of bounds.
+func (vec *UnsafeDiBit) State(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= vec.Count {
+		return 0, errors.Errorf("index too large for dibit count (max: %v)", vec.Count)
+	}
+
+	return readBits(vec.Data, vec.Layout, index*DIBITSIZE, DIBITSIZE), nil
+}
+
+// Indexes is a method of UnsafeDiBit that returns the slice of indexes matching the given state.
+// Returns an error if state value exceeds the maximum for the UnsafeDiBit.
+func (vec *UnsafeDiBit) Indexes(state uint64) ([]uint64, error) {
+	// Check for state value too large for UnsafeDiBit
+	if state > vec.MaxState() {
+		return nil, errors.Errorf("state too large for dibit state (max: %v)", vec.MaxState())
+	}
+
+	indexes := make([]uint64, 0)
+	reader := slotReader{data: vec.Data, layout: vec.Layout, size: DIBITSIZE}
+	for i := uint64(0); i < vec.Count; i++ {
+		if reader.read() == state {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
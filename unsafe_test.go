@@ -0,0 +1,122 @@
+package bitvec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsafeBitVec(t *testing.T) {
+	rng := rand.New(rand.NewSource(38))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		for _, size := range []uint64{1, 2, 7, 64} {
+			vec, err := NewBitVecWithLayout(500, size, layout)
+			require.Nil(t, err, "Unexpected Error")
+
+			unsafe, err := NewUnsafeBitVecWithLayout(500, size, layout)
+			require.Nil(t, err, "Unexpected Error")
+
+			for n := 0; n < 1000; n++ {
+				index, state := uint64(rng.Intn(500)), rng.Uint64()&vec.MaxState()
+				if rng.Intn(4) == 0 {
+					require.Nil(t, vec.Unset(index))
+					require.Nil(t, unsafe.Unset(index))
+				} else {
+					require.Nil(t, vec.Set(index, state))
+					require.Nil(t, unsafe.Set(index, state))
+				}
+			}
+
+			assert.Equal(t, vec.Data, unsafe.Data)
+			assert.Equal(t, vec.String(), unsafe.String())
+
+			state, _ := vec.State(123)
+			output, err := unsafe.State(123)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, state, output)
+
+			exists, err := unsafe.Has(123, state)
+			require.Nil(t, err, "Unexpected Error")
+			assert.True(t, exists)
+
+			expected, _ := vec.Indexes(0)
+			indexes, err := unsafe.Indexes(0)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, expected, indexes)
+		}
+	}
+
+	_, err := NewUnsafeBitVec(10, 70)
+	assert.EqualError(t, err, "state size greater 64 not allowed")
+
+	_, err = NewUnsafeBitVecWithLayout(10, 2, Layout(3))
+	assert.EqualError(t, err, "unknown bit layout: 3")
+
+	vec, _ := NewUnsafeBitVec(10, 2)
+	assert.EqualError(t, vec.Set(10, 1), "index too large for bitvec count (max: 10)")
+	assert.EqualError(t, vec.Set(1, 4), "state too large for bitvec state (max: 3)")
+	assert.EqualError(t, vec.Unset(10), "index too large for bitvec count (max: 10)")
+
+	_, err = vec.Has(1, 4)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 3)")
+
+	_, err = vec.State(10)
+	assert.EqualError(t, err, "index too large for bitvec count (max: 10)")
+
+	_, err = vec.Indexes(4)
+	assert.EqualError(t, err, "state too large for bitvec state (max: 3)")
+}
+
+func TestUnsafeDiBit(t *testing.T) {
+	rng := rand.New(rand.NewSource(38))
+
+	for _, layout := range []Layout{MSBFirst, LSBFirst} {
+		vec, err := NewDiBitWithLayout(500, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		unsafe, err := NewUnsafeDiBitWithLayout(500, layout)
+		require.Nil(t, err, "Unexpected Error")
+
+		for n := 0; n < 1000; n++ {
+			index, state := uint64(rng.Intn(500)), uint64(rng.Intn(4))
+			if rng.Intn(4) == 0 {
+				require.Nil(t, vec.Unset(index))
+				require.Nil(t, unsafe.Unset(index))
+			} else {
+				require.Nil(t, vec.Set(index, state))
+				require.Nil(t, unsafe.Set(index, state))
+			}
+		}
+
+		assert.Equal(t, vec.Data, unsafe.Data)
+		assert.Equal(t, vec.String(), unsafe.String())
+
+		for state := uint64(0); state < 4; state++ {
+			expected, _ := vec.Indexes(state)
+			indexes, err := unsafe.Indexes(state)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, expected, indexes)
+		}
+	}
+
+	_, err := NewUnsafeDiBitWithLayout(10, Layout(3))
+	assert.EqualError(t, err, "unknown bit layout: 3")
+
+	vec := NewUnsafeDiBit(10)
+	assert.Equal(t, uint64(3), vec.MaxState())
+	assert.EqualError(t, vec.Set(10, 1), "index too large for dibit count (max: 10)")
+	assert.EqualError(t, vec.Set(1, 4), "state too large for dibit state (max: 3)")
+	assert.EqualError(t, vec.Unset(10), "index too large for dibit count (max: 10)")
+
+	_, err = vec.Has(10, 1)
+	assert.EqualError(t, err, "index too large for dibit count (max: 10)")
+
+	_, err = vec.State(10)
+	assert.EqualError(t, err, "index too large for dibit count (max: 10)")
+
+	_, err = vec.Indexes(4)
+	assert.EqualError(t, err, "state too large for dibit state (max: 3)")
+}
@@ -0,0 +1,265 @@
+package bitvec
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WaveletTree is a read-only index over the states of a BitVec that answers rank, select,
+// range counting and quantile queries in O(Size) time, independent of the Count.
+//
+// The tree is stored level-wise (as a wavelet matrix): level l holds one bit for every
+// response, the l-th most significant bit of its state, with the responses reordered
+// such that those with a 0 bit at the previous level precede those with a 1 bit.
+type WaveletTree struct {
+	// Count is the number of responses
+	Count uint64
+	// Size is the number of bits required for a response
+	Size uint64
+
+	// levels holds the bits of the responses for every level, most significant first
+	levels []waveletLevel
+}
+
+// waveletLevel is a single level of a WaveletTree.
+type waveletLevel struct {
+	// data stores one bit for every response in the MSBFirst layout
+	data []uint64
+	// rank is the rank directory over data
+	rank *rankDirectory
+	// zeros is the number of 0 bits in data
+	zeros uint64
+}
+
+// ones returns the number of 1 bits in the level before the given position.
+func (level *waveletLevel) ones(count, pos uint64) uint64 {
+	return rank(level.data, MSBFirst, count, 1, level.rank, 1, pos)
+}
+
+// NewWaveletTree is a constructor function for WaveletTree that indexes a snapshot of the states of a BitVec.
+// Later modifications of the BitVec are not reflected in the WaveletTree.
+func NewWaveletTree(vec *BitVec) *WaveletTree {
+	states := vec.ToStates()
+	tree := &WaveletTree{Count: uint64(len(states)), Size: vec.Size, levels: make([]waveletLevel, vec.Size)}
+
+	next := make([]uint64, 0, len(states))
+	for l := range tree.levels {
+		shift := tree.Size - 1 - uint64(l)
+		level := &tree.levels[l]
+		level.data = make([]uint64, (tree.Count+63)/64)
+
+		// Set the bit of every response and partition the responses by it,
+		// keeping the order of responses with the same bit
+		next = next[:0]
+		for i, state := range states {
+			if (state>>shift)&1 == 1 {
+				level.data[i/64] |= 1 << (63 - i%64)
+			} else {
+				next = append(next, state)
+			}
+		}
+
+		level.zeros = uint64(len(next))
+		for _, state := range states {
+			if (state>>shift)&1 == 1 {
+				next = append(next, state)
+			}
+		}
+
+		level.rank = newRankDirectory(level.data, MSBFirst, tree.Count, 1)
+		states, next = next, states
+	}
+
+	return tree
+}
+
+// MaxState is a method of WaveletTree that returns the maximum value for a state.
+// It is calculated as 2^StateBits-1.
+func (tree *WaveletTree) MaxState() uint64 {
+	return 1<<tree.Size - 1
+}
+
+// checkRange returns an error if [from, to) is not a valid range of indexes.
+func (tree *WaveletTree) checkRange(from, to uint64) error {
+	if to > tree.Count {
+		return errors.Errorf("index too large for wavelet tree count (max: %v)", tree.Count)
+	}
+
+	if from > to {
+		return errors.Errorf("range start after range end (start: %v, end: %v)", from, to)
+	}
+
+	return nil
+}
+
+// Access is a method of WaveletTree that returns the state at a given index.
+// Returns an error if the index is out of bounds.
+func (tree *WaveletTree) Access(index uint64) (uint64, error) {
+	// Check for out of bounds index
+	if index >= tree.Count {
+		return 0, errors.Errorf("index too large for wavelet tree count (max: %v)", tree.Count)
+	}
+
+	var state uint64
+	for l := range tree.levels {
+		level := &tree.levels[l]
+
+		// Follow the response into the partition for its bit
+		ones := level.ones(tree.Count, index)
+		if level.data[index/64]>>(63-index%64)&1 == 1 {
+			state |= 1 << (tree.Size - 1 - uint64(l))
+			index = level.zeros + ones
+		} else {
+			index -= ones
+		}
+	}
+
+	return state, nil
+}
+
+// RangeFreq is a method of WaveletTree that returns the number of responses in [from, to) with the given state.
+// Returns an error if the range is invalid or if the state value exceeds the maximum for the WaveletTree.
+func (tree *WaveletTree) RangeFreq(from, to, state uint64) (uint64, error) {
+	if err := tree.checkRange(from, to); err != nil {
+		return 0, err
+	}
+
+	// Check for state value too large for WaveletTree
+	if state > tree.MaxState() {
+		return 0, errors.Errorf("state too large for wavelet tree state (max: %v)", tree.MaxState())
+	}
+
+	for l := range tree.levels {
+		level := &tree.levels[l]
+
+		// Narrow the range to the partition for the bit of the state
+		fromOnes, toOnes := level.ones(tree.Count, from), level.ones(tree.Count, to)
+		if (state>>(tree.Size-1-uint64(l)))&1 == 1 {
+			from, to = level.zeros+fromOnes, level.zeros+toOnes
+		} else {
+			from, to = from-fromOnes, to-toOnes
+		}
+	}
+
+	return to - from, nil
+}
+
+// Rank is a method of WaveletTree that returns the number of occurrences of the given state before the given index.
+// Returns an error if the index is greater than the Count or if the state value exceeds the maximum for the WaveletTree.
+func (tree *WaveletTree) Rank(state, index uint64) (uint64, error) {
+	return tree.RangeFreq(0, index, state)
+}
+
+// Select is a method of WaveletTree that returns the index of the k-th occurrence (counting from 0) of the given state.
+// Returns an error if the state value exceeds the maximum for the WaveletTree or if there are not more than k occurrences of the state.
+func (tree *WaveletTree) Select(state, k uint64) (uint64, error) {
+	// Check for state value too large for WaveletTree
+	if state > tree.MaxState() {
+		return 0, errors.Errorf("state too large for wavelet tree state (max: %v)", tree.MaxState())
+	}
+
+	// Find the partition of the responses with the state at the bottom of the tree
+	from, to := uint64(0), tree.Count
+	for l := range tree.levels {
+		level := &tree.levels[l]
+
+		fromOnes, toOnes := level.ones(tree.Count, from), level.ones(tree.Count, to)
+		if (state>>(tree.Size-1-uint64(l)))&1 == 1 {
+			from, to = level.zeros+fromOnes, level.zeros+toOnes
+		} else {
+			from, to = from-fromOnes, to-toOnes
+		}
+	}
+
+	// Check for an occurrence that does not exist
+	if k >= to-from {
+		return 0, errors.Errorf("not enough occurrences of state for select (count: %v)", to-from)
+	}
+
+	// Follow the occurrence back up to its original index
+	index := from + k
+	for l := len(tree.levels) - 1; l >= 0; l-- {
+		level := &tree.levels[l]
+
+		// Errors can be ignored because the occurrence is known to exist at every level
+		if (state>>(tree.Size-1-uint64(l)))&1 == 1 {
+			index, _ = selectIndex(level.data, MSBFirst, tree.Count, 1, level.rank, 1, index-level.zeros)
+		} else {
+			index, _ = selectIndex(level.data, MSBFirst, tree.Count, 1, level.rank, 0, index)
+		}
+	}
+
+	return index, nil
+}
+
+// countAtMost returns the number of responses in [from, to) with a state of at most the given state.
+func (tree *WaveletTree) countAtMost(from, to, state uint64) uint64 {
+	var total uint64
+	for l := range tree.levels {
+		level := &tree.levels[l]
+
+		fromOnes, toOnes := level.ones(tree.Count, from), level.ones(tree.Count, to)
+		if (state>>(tree.Size-1-uint64(l)))&1 == 1 {
+			// Responses with a 0 bit where the state has a 1 bit are smaller
+			total += (to - toOnes) - (from - fromOnes)
+			from, to = level.zeros+fromOnes, level.zeros+toOnes
+		} else {
+			from, to = from-fromOnes, to-toOnes
+		}
+	}
+
+	return total + to - from
+}
+
+// RangeCount is a method of WaveletTree that returns the number of responses in [from, to)
+// with a state in [lo, hi]. Returns an error if the range of indexes is invalid.
+func (tree *WaveletTree) RangeCount(from, to, lo, hi uint64) (uint64, error) {
+	if err := tree.checkRange(from, to); err != nil {
+		return 0, err
+	}
+
+	// Clamp the states to those that can occur
+	if hi > tree.MaxState() {
+		hi = tree.MaxState()
+	}
+
+	if lo > hi {
+		return 0, nil
+	}
+
+	total := tree.countAtMost(from, to, hi)
+	if lo > 0 {
+		total -= tree.countAtMost(from, to, lo-1)
+	}
+
+	return total, nil
+}
+
+// Quantile is a method of WaveletTree that returns the k-th smallest state (counting from 0)
+// among the responses in [from, to). Returns an error if the range is invalid or has no more than k responses.
+func (tree *WaveletTree) Quantile(from, to, k uint64) (uint64, error) {
+	if err := tree.checkRange(from, to); err != nil {
+		return 0, err
+	}
+
+	// Check for a quantile beyond the responses in the range
+	if k >= to-from {
+		return 0, errors.Errorf("quantile too large for range (max: %v)", to-from)
+	}
+
+	var state uint64
+	for l := range tree.levels {
+		level := &tree.levels[l]
+
+		// Descend into the 0 partition if it holds more than k responses
+		fromOnes, toOnes := level.ones(tree.Count, from), level.ones(tree.Count, to)
+		if zeros := (to - toOnes) - (from - fromOnes); k >= zeros {
+			k -= zeros
+			state |= 1 << (tree.Size - 1 - uint64(l))
+			from, to = level.zeros+fromOnes, level.zeros+toOnes
+		} else {
+			from, to = from-fromOnes, to-toOnes
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,123 @@
+package bitvec
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaveletTree(t *testing.T) {
+	rng := rand.New(rand.NewSource(30))
+
+	vec, err := NewBitVecWithLayout(1000, 5, LSBFirst)
+	require.Nil(t, err, "Unexpected Error")
+
+	states := make([]uint64, vec.Count)
+	for i := range states {
+		states[i] = uint64(rng.Intn(20))
+		require.Nil(t, vec.Set(uint64(i), states[i]))
+	}
+
+	tree := NewWaveletTree(vec)
+	assert.Equal(t, vec.Count, tree.Count)
+	assert.Equal(t, uint64(31), tree.MaxState())
+
+	for i, state := range states {
+		value, err := tree.Access(uint64(i))
+		require.Nil(t, err, "Unexpected Error")
+		require.Equal(t, state, value)
+	}
+
+	for n := 0; n < 200; n++ {
+		from := uint64(rng.Intn(int(vec.Count)))
+		to := from + uint64(rng.Intn(int(vec.Count-from)+1))
+		lo, hi := uint64(rng.Intn(32)), uint64(rng.Intn(32))
+		window := states[from:to]
+
+		var freq, count uint64
+		for _, state := range window {
+			if state == lo {
+				freq++
+			}
+
+			if lo <= state && state <= hi {
+				count++
+			}
+		}
+
+		result, err := tree.RangeFreq(from, to, lo)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, freq, result)
+
+		result, err = tree.RangeCount(from, to, lo, hi)
+		require.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, count, result)
+
+		sorted := append([]uint64(nil), window...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		for k := range sorted {
+			result, err = tree.Quantile(from, to, uint64(k))
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, sorted[k], result)
+		}
+
+		_, err = tree.Quantile(from, to, uint64(len(sorted)))
+		assert.Error(t, err)
+	}
+
+	for state := uint64(0); state < 20; state++ {
+		var k uint64
+		for i, value := range states {
+			if value != state {
+				continue
+			}
+
+			index, err := tree.Select(state, k)
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, uint64(i), index)
+
+			rank, err := tree.Rank(state, uint64(i))
+			require.Nil(t, err, "Unexpected Error")
+			assert.Equal(t, k, rank)
+			k++
+		}
+
+		_, err := tree.Select(state, k)
+		assert.Error(t, err)
+	}
+}
+
+func TestWaveletTree_Errors(t *testing.T) {
+	vec, err := NewBitVecFromStates(3, []uint64{1, 5, 7, 0})
+	require.Nil(t, err, "Unexpected Error")
+	tree := NewWaveletTree(vec)
+
+	_, err = tree.Access(4)
+	assert.EqualError(t, err, "index too large for wavelet tree count (max: 4)")
+
+	_, err = tree.RangeFreq(0, 5, 1)
+	assert.EqualError(t, err, "index too large for wavelet tree count (max: 4)")
+
+	_, err = tree.RangeFreq(3, 2, 1)
+	assert.EqualError(t, err, "range start after range end (start: 3, end: 2)")
+
+	_, err = tree.RangeFreq(0, 4, 8)
+	assert.EqualError(t, err, "state too large for wavelet tree state (max: 7)")
+
+	_, err = tree.Select(6, 0)
+	assert.EqualError(t, err, "not enough occurrences of state for select (count: 0)")
+
+	_, err = tree.Quantile(1, 1, 0)
+	assert.EqualError(t, err, "quantile too large for range (max: 0)")
+
+	count, err := tree.RangeCount(0, 4, 5, 100)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(2), count)
+
+	count, err = tree.RangeCount(0, 4, 6, 2)
+	require.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, uint64(0), count)
+}